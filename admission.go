@@ -0,0 +1,165 @@
+package cache
+
+import (
+	"errors"
+	"hash/maphash"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrNotAdmitted is returned by Set if a full cache with an Admission rejects a new key.
+var ErrNotAdmitted = errors.New("Key not admitted.")
+
+// Admission decides whether new keys may enter a full cache.
+// Eviction policies consult it before evicting items to make room for a key that is not in cache.
+// Implementations must be safe for concurrent use.
+type Admission interface {
+	// Record counts an access to a key, it is called on every Get.
+	Record(k interface{})
+	// Admit reports whether a new key should replace the victim chosen by the eviction policy.
+	Admit(k, victim interface{}) bool
+}
+
+// admit checks whether an item may evict victim to enter a full cache.
+// Items heavier than the capacity are rejected with ErrMaxSize before anything is evicted
+// and keys already in cache are always admitted.
+func (c *Cache) admit(k, v, victim interface{}) error {
+	if c.oversized(k, v) {
+		return ErrMaxSize
+	}
+	if c.admission == nil {
+		return nil
+	}
+	c.mu.RLock()
+	_, ok := c.values[k]
+	c.mu.RUnlock()
+	if !ok && !c.admission.Admit(k, victim) {
+		return ErrNotAdmitted
+	}
+	return nil
+}
+
+// Doorkeeper admits keys the second time they are set to a full cache so that keys seen once do not displace cached items.
+// Sightings are kept in a Bloom filter that is cleared after n keys.
+type Doorkeeper struct {
+	bloom *Bloom
+	n     int64
+	seen  int64
+}
+
+var _ Admission = (*Doorkeeper)(nil)
+
+// NewDoorkeeper returns a Doorkeeper remembering up to n keys with a false positive rate of p.
+func NewDoorkeeper(n int, p float64) *Doorkeeper {
+	if n < 1 {
+		n = 1
+	}
+	return &Doorkeeper{
+		bloom: NewBloom(n, p),
+		n:     int64(n),
+	}
+}
+
+// Record implements Admission, lookups are not sightings.
+func (d *Doorkeeper) Record(k interface{}) {}
+
+// Admit implements Admission.
+func (d *Doorkeeper) Admit(k, victim interface{}) bool {
+	if d.bloom.Test(k) {
+		return true
+	}
+	d.bloom.Add(k)
+	if atomic.AddInt64(&d.seen, 1) >= d.n {
+		atomic.StoreInt64(&d.seen, 0)
+		d.bloom.Rebuild(func(func(interface{}) bool) {})
+	}
+	return false
+}
+
+// sketchDepth is the number of rows of a Frequency sketch.
+const sketchDepth = 4
+
+// sketchSeeds are odd multipliers that hash keys independently for each row.
+var sketchSeeds = [sketchDepth]uint64{0x9e3779b97f4a7c15, 0xc2b2ae3d27d4eb4f, 0x165667b19e3779f9, 0xd6e8feb86659fd93}
+
+// Frequency admits keys that are accessed more often than the victim they replace as in TinyLFU.
+// Accesses are counted in a count-min sketch that is halved every 10 accesses per item so that old popularity fades.
+type Frequency struct {
+	counters []uint32
+	width    uint64
+	shift    uint
+	seed     maphash.Seed
+	sample   int64
+	count    int64
+	mu       sync.Mutex
+}
+
+var _ Admission = (*Frequency)(nil)
+
+// NewFrequency returns a Frequency sized for a cache of n items.
+func NewFrequency(n int) *Frequency {
+	if n < 16 {
+		n = 16
+	}
+	width := 1 << bits.Len(uint(n-1))
+	return &Frequency{
+		counters: make([]uint32, sketchDepth*width),
+		width:    uint64(width),
+		shift:    uint(64 - bits.Len(uint(width-1))),
+		seed:     maphash.MakeSeed(),
+		sample:   int64(10 * n),
+	}
+}
+
+// slots calls fn with the counter of a key in each row.
+// Rows use multiplicative hashing with different seeds so that keys rarely collide in all rows.
+func (f *Frequency) slots(k interface{}, fn func(c *uint32)) {
+	h := maphash.Comparable(f.seed, k)
+	for i, seed := range sketchSeeds {
+		fn(&f.counters[uint64(i)*f.width+(h*seed)>>f.shift])
+	}
+}
+
+// Record implements Admission.
+func (f *Frequency) Record(k interface{}) {
+	f.slots(k, func(c *uint32) {
+		atomic.AddUint32(c, 1)
+	})
+	if atomic.AddInt64(&f.count, 1) >= f.sample && f.mu.TryLock() {
+		if atomic.LoadInt64(&f.count) >= f.sample {
+			f.age()
+		}
+		f.mu.Unlock()
+	}
+}
+
+// age halves all counters, the caller must hold the lock.
+func (f *Frequency) age() {
+	for i := range f.counters {
+		for {
+			n := atomic.LoadUint32(&f.counters[i])
+			if atomic.CompareAndSwapUint32(&f.counters[i], n, n/2) {
+				break
+			}
+		}
+	}
+	atomic.StoreInt64(&f.count, atomic.LoadInt64(&f.count)/2)
+}
+
+// Estimate returns the estimated number of recent accesses to a key.
+func (f *Frequency) Estimate(k interface{}) uint32 {
+	n := ^uint32(0)
+	f.slots(k, func(c *uint32) {
+		if v := atomic.LoadUint32(c); v < n {
+			n = v
+		}
+	})
+	return n
+}
+
+// Admit implements Admission counting the insert as an access.
+func (f *Frequency) Admit(k, victim interface{}) bool {
+	f.Record(k)
+	return f.Estimate(k) > f.Estimate(victim)
+}
@@ -0,0 +1,98 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+func Test_Doorkeeper(t *testing.T) {
+	for _, policy := range policies[1:] {
+		c, err := cache.Build(cache.WithCapacity(2), cache.WithPolicy(policy), cache.WithAdmission(cache.NewDoorkeeper(100, 0.01)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Set("foo", 1, time.Time{})
+		c.Set("bar", 2, time.Time{})
+		if err := c.Set("baz", 3, time.Time{}); err != cache.ErrNotAdmitted {
+			t.Errorf("%s: Invalid error %v", policy, err)
+		}
+		if n := c.Evict(); n != 2 {
+			t.Errorf("%s: Invalid size %d", policy, n)
+		}
+		// Existing keys are always admitted
+		if err := c.Set("foo", 4, time.Time{}); err != nil {
+			t.Errorf("%s: Invalid error %v", policy, err)
+		}
+		if err := c.Set("baz", 3, time.Time{}); err != nil {
+			t.Errorf("%s: Invalid error %v", policy, err)
+		}
+		if v, _, err := c.Get("baz"); err != nil || v != 3 {
+			t.Errorf("%s: Invalid value %v %v", policy, v, err)
+		}
+		if n := c.Evict(); n != 2 {
+			t.Errorf("%s: Invalid size %d", policy, n)
+		}
+	}
+}
+
+func Test_DoorkeeperReset(t *testing.T) {
+	// A tiny false positive rate keeps new keys from being mistaken for seen ones
+	d := cache.NewDoorkeeper(100, 1e-9)
+	if d.Admit("foo", nil) {
+		t.Errorf("Admitted on first sighting")
+	}
+	if !d.Admit("foo", nil) {
+		t.Errorf("Not admitted on second sighting")
+	}
+	// The filter is cleared after 100 keys
+	for i := 0; i < 100; i++ {
+		d.Admit(i, nil)
+	}
+	if d.Admit("foo", nil) {
+		t.Errorf("Admitted after reset")
+	}
+}
+
+func Test_Frequency(t *testing.T) {
+	f := cache.NewFrequency(100)
+	c, err := cache.Build(cache.WithCapacity(2), cache.WithPolicy(cache.PolicyLRU), cache.WithAdmission(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set("foo", 1, time.Time{})
+	c.Set("bar", 2, time.Time{})
+	c.Get("foo")
+	c.Get("foo")
+	c.Get("bar")
+	c.Get("bar")
+	if err := c.Set("baz", 3, time.Time{}); err != cache.ErrNotAdmitted {
+		t.Errorf("Invalid error %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		c.Get("baz")
+	}
+	if n := f.Estimate("baz"); n < 4 {
+		t.Errorf("Invalid estimate %d", n)
+	}
+	if err := c.Set("baz", 3, time.Time{}); err != nil {
+		t.Errorf("Invalid error %v", err)
+	}
+	if _, _, err := c.Get("baz"); err != nil {
+		t.Errorf("Invalid error %v", err)
+	}
+}
+
+func Test_FrequencyAging(t *testing.T) {
+	f := cache.NewFrequency(16)
+	for i := 0; i < 100; i++ {
+		f.Record("foo")
+	}
+	for i := 0; i < 100; i++ {
+		f.Record(i)
+	}
+	if n := f.Estimate("foo"); n >= 100 || n < 25 {
+		t.Errorf("Invalid estimate %d", n)
+	}
+}
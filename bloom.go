@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"hash/maphash"
+	"iter"
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+// Filter reports whether a key may exist upstream.
+// Filters must not return false for keys that exist.
+type Filter interface {
+	Test(k interface{}) bool
+}
+
+// Bloom is a Bloom filter of keys safe for concurrent use.
+type Bloom struct {
+	bits atomic.Pointer[[]uint64]
+	m    uint64
+	k    int
+	seed maphash.Seed
+}
+
+var _ Filter = (*Bloom)(nil)
+
+// NewBloom returns a Bloom filter sized for n keys with a false positive rate of p.
+func NewBloom(n int, p float64) *Bloom {
+	if n < 1 {
+		n = 1
+	}
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	m = (m + 63) &^ 63
+	k := int(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	b := &Bloom{m: m, k: k, seed: maphash.MakeSeed()}
+	words := make([]uint64, m/64)
+	b.bits.Store(&words)
+	return b
+}
+
+// positions calls fn with the bit positions of a key using double hashing.
+func (b *Bloom) positions(k interface{}, fn func(i uint64) bool) {
+	h := maphash.Comparable(b.seed, k)
+	h1, h2 := h&math.MaxUint32, h>>32|1
+	for i := 0; i < b.k; i++ {
+		if !fn((h1 + uint64(i)*h2) % b.m) {
+			return
+		}
+	}
+}
+
+// Add adds a key to the filter.
+func (b *Bloom) Add(k interface{}) {
+	b.add(*b.bits.Load(), k)
+}
+
+func (b *Bloom) add(words []uint64, k interface{}) {
+	b.positions(k, func(i uint64) bool {
+		w, mask := &words[i/64], uint64(1)<<(i%64)
+		for {
+			old := atomic.LoadUint64(w)
+			if old&mask != 0 || atomic.CompareAndSwapUint64(w, old, old|mask) {
+				return true
+			}
+		}
+	})
+}
+
+// Test reports whether a key may have been added to the filter.
+func (b *Bloom) Test(k interface{}) bool {
+	words := *b.bits.Load()
+	ok := true
+	b.positions(k, func(i uint64) bool {
+		ok = atomic.LoadUint64(&words[i/64])&(1<<(i%64)) != 0
+		return ok
+	})
+	return ok
+}
+
+// Rebuild replaces the filter contents with the keys of a source.
+// Tests during the rebuild use the previous contents and keys added during the rebuild
+// are lost unless the source yields them.
+func (b *Bloom) Rebuild(keys iter.Seq[interface{}]) {
+	words := make([]uint64, b.m/64)
+	for k := range keys {
+		b.add(words, k)
+	}
+	b.bits.Store(&words)
+}
+
+// FalsePositiveRate estimates the false positive rate of the filter from the ratio of set bits.
+func (b *Bloom) FalsePositiveRate() float64 {
+	words := *b.bits.Load()
+	n := 0
+	for i := range words {
+		n += bits.OnesCount64(atomic.LoadUint64(&words[i]))
+	}
+	return math.Pow(float64(n)/float64(b.m), float64(b.k))
+}
@@ -0,0 +1,71 @@
+package cache_test
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+func Test_Bloom(t *testing.T) {
+	b := cache.NewBloom(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		b.Add(i)
+	}
+	for i := 0; i < 1000; i++ {
+		if !b.Test(i) {
+			t.Fatalf("False negative %d", i)
+		}
+	}
+	fp := 0
+	for i := 1000; i < 11000; i++ {
+		if b.Test(i) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / 10000; rate > 0.03 {
+		t.Errorf("Invalid false positive rate %f", rate)
+	}
+	if rate := b.FalsePositiveRate(); rate <= 0 || rate > 0.03 {
+		t.Errorf("Invalid false positive estimate %f", rate)
+	}
+	b.Rebuild(func(yield func(interface{}) bool) {
+		yield("foo")
+	})
+	if !b.Test("foo") || b.Test(1) {
+		t.Error("Invalid rebuild")
+	}
+}
+
+func Test_ProxyFilter(t *testing.T) {
+	b := cache.NewBloom(100, 0.01)
+	for i := 0; i < 10; i++ {
+		b.Add(fmt.Sprint(i))
+	}
+	var loads int64
+	p := cache.Proxy(cache.UpstreamFunc(func(x interface{}) (interface{}, time.Time, error) {
+		atomic.AddInt64(&loads, 1)
+		if x == "5" || strings.HasPrefix(x.(string), "missing") {
+			return nil, time.Time{}, cache.ErrKeyNotFound
+		}
+		return x, time.Time{}, nil
+	}), cache.NewLRU(10), cache.WithFilter(b))
+	if v, _, err := p.Get("1"); err != nil || v != "1" {
+		t.Errorf("Invalid value %v %v", v, err)
+	}
+	for i := 0; i < 100; i++ {
+		if _, _, err := p.Get(fmt.Sprint("missing", i)); err != cache.ErrKeyNotFound {
+			t.Errorf("Invalid error %v", err)
+		}
+	}
+	if _, _, err := p.Get("5"); err != cache.ErrKeyNotFound {
+		t.Errorf("Invalid error %v", err)
+	}
+	m := p.(interface{ Metrics() cache.Metrics }).Metrics()
+	if m.Rejected+uint64(loads) != 102 || m.Rejected < 95 || m.FalsePositive < 1 || m.FalsePositive != m.Load-1 {
+		t.Errorf("Invalid metrics %#v", m)
+	}
+}
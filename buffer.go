@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"math/bits"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// accessBuffer records cache hits for eviction policies.
+// Hits are spread over stripes chosen at random so that hot keys do not contend on a single lock.
+// Recording never blocks, hits are dropped if a stripe is busy or full.
+type accessBuffer struct {
+	stripes []stripe
+	mask    uint32
+}
+
+type stripe struct {
+	stripeData
+	// pad stripes to the 64 byte size of a cache line to limit false sharing between neighbours
+	_ [64 - unsafe.Sizeof(stripeData{})%64]byte
+}
+
+type stripeData struct {
+	mu   sync.Mutex
+	keys []interface{}
+}
+
+// newAccessBuffer returns a buffer holding about size hits.
+func newAccessBuffer(size int) *accessBuffer {
+	n := runtime.GOMAXPROCS(0)
+	n = 1 << bits.Len(uint(n-1))
+	if n > 64 {
+		n = 64
+	}
+	per := size / n
+	if per < 16 {
+		per = 16
+	}
+	b := &accessBuffer{
+		stripes: make([]stripe, n),
+		mask:    uint32(n - 1),
+	}
+	for i := range b.stripes {
+		b.stripes[i].keys = make([]interface{}, 0, per)
+	}
+	return b
+}
+
+// record adds a hit and reports whether its stripe is full and should be drained.
+func (b *accessBuffer) record(k interface{}) (full bool) {
+	s := &b.stripes[rand.Uint32()&b.mask]
+	if !s.mu.TryLock() {
+		return false
+	}
+	if len(s.keys) < cap(s.keys) {
+		s.keys = append(s.keys, k)
+	}
+	full = len(s.keys) == cap(s.keys)
+	s.mu.Unlock()
+	return
+}
+
+// drain calls fn for each recorded hit and empties the buffer.
+// The caller must hold the policy lock.
+func (b *accessBuffer) drain(fn func(k interface{})) {
+	for i := range b.stripes {
+		s := &b.stripes[i]
+		s.mu.Lock()
+		for j, k := range s.keys {
+			fn(k)
+			s.keys[j] = nil
+		}
+		s.keys = s.keys[:0]
+		s.mu.Unlock()
+	}
+}
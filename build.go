@@ -0,0 +1,269 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidCapacity is returned by Build for negative capacities or missing capacity with an eviction policy or weigher.
+	ErrInvalidCapacity = errors.New("Invalid capacity.")
+	// ErrInvalidPolicy is returned by Build for unknown eviction policies.
+	ErrInvalidPolicy = errors.New("Invalid eviction policy.")
+	// ErrInvalidShards is returned by Build if shards are negative or exceed the capacity.
+	ErrInvalidShards = errors.New("Invalid number of shards.")
+	// ErrInvalidTTL is returned by Build for negative TTL, a max lifetime shorter than the sliding TTL or jitter out of [0, 1).
+	ErrInvalidTTL = errors.New("Invalid default TTL.")
+	// ErrInvalidJanitor is returned by Build for non positive janitor intervals or a metrics sink without janitor.
+	ErrInvalidJanitor = errors.New("Invalid janitor interval.")
+	// ErrInvalidClock is returned by Build for a nil clock.
+	ErrInvalidClock = errors.New("Invalid clock.")
+)
+
+// MetricsSink receives metrics changes from the janitor of a cache.
+type MetricsSink interface {
+	Observe(delta Metrics)
+}
+
+// MetricsSinkFunc adapts a function to MetricsSink.
+type MetricsSinkFunc func(delta Metrics)
+
+// Observe implements MetricsSink.
+func (f MetricsSinkFunc) Observe(delta Metrics) {
+	f(delta)
+}
+
+type config struct {
+	policy   EvictionPolicy
+	capacity int
+	weigher  Weigher
+	ttl      time.Duration
+	sliding  bool
+	lifetime time.Duration
+	jitter   float64
+	interval time.Duration
+	done     <-chan struct{}
+	onEvict  EvictHook
+	shards   int
+	clock    Clock
+	sink     MetricsSink
+	admit    Admission
+}
+
+// Option configures a cache created by Build.
+type Option func(c *config)
+
+// WithPolicy sets the eviction policy.
+func WithPolicy(policy EvictionPolicy) Option {
+	return func(c *config) {
+		c.policy = policy
+	}
+}
+
+// WithCapacity sets the maximum number of items or, with a Weigher, the maximum total weight.
+func WithCapacity(capacity int) Option {
+	return func(c *config) {
+		c.capacity = capacity
+	}
+}
+
+// WithWeigher counts the weight of items against the capacity instead of their number.
+func WithWeigher(w Weigher) Option {
+	return func(c *config) {
+		c.weigher = w
+	}
+}
+
+// WithDefaultTTL sets the TTL of items set with a zero expiration time.
+// Use Never to set items without expiration.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.ttl = ttl
+	}
+}
+
+// WithSlidingTTL sets a default TTL that is extended by ttl on every hit.
+// If maxLifetime is positive items expire at most maxLifetime after they were set.
+// It overrides WithDefaultTTL.
+func WithSlidingTTL(ttl, maxLifetime time.Duration) Option {
+	return func(c *config) {
+		c.ttl = ttl
+		c.sliding = true
+		c.lifetime = maxLifetime
+	}
+}
+
+// WithJitter shortens the expiration time of items by a random fraction up to fraction of their TTL
+// so that items set together do not expire together.
+func WithJitter(fraction float64) Option {
+	return func(c *config) {
+		c.jitter = fraction
+	}
+}
+
+// WithJanitor removes expired items every interval until done is closed.
+func WithJanitor(interval time.Duration, done <-chan struct{}) Option {
+	return func(c *config) {
+		c.interval = interval
+		c.done = done
+	}
+}
+
+// WithOnEvict sets a hook called for each item removed from the cache.
+func WithOnEvict(hook EvictHook) Option {
+	return func(c *config) {
+		c.onEvict = hook
+	}
+}
+
+// WithShards splits the cache and its capacity into n independent shards.
+// Shard capacities differ by at most one and add up to the capacity.
+func WithShards(n int) Option {
+	return func(c *config) {
+		c.shards = n
+	}
+}
+
+// WithClock sets the clock used to check expiration and schedule the janitor.
+func WithClock(clock Clock) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// WithAdmission rejects new keys that the admission does not admit to a full cache with ErrNotAdmitted.
+// It has no effect without an eviction policy.
+// Shards share the admission.
+func WithAdmission(a Admission) Option {
+	return func(c *config) {
+		c.admit = a
+	}
+}
+
+// WithMetricsSink reports metrics changes to a sink on each janitor run.
+func WithMetricsSink(sink MetricsSink) Option {
+	return func(c *config) {
+		c.sink = sink
+	}
+}
+
+func (c *config) validate() error {
+	switch c.policy {
+	case PolicyNone:
+	case PolicyFIFO, PolicyLRU, PolicyLFU, PolicyTTL, PolicyCLOCK:
+		if c.capacity == 0 {
+			return ErrInvalidCapacity
+		}
+	default:
+		return ErrInvalidPolicy
+	}
+	switch {
+	case c.capacity < 0, c.weigher != nil && c.capacity == 0:
+		return ErrInvalidCapacity
+	case c.shards < 0, c.capacity > 0 && c.shards > c.capacity:
+		return ErrInvalidShards
+	case c.ttl < 0, c.sliding && c.ttl == 0, c.lifetime < 0, c.lifetime > 0 && c.lifetime < c.ttl, c.jitter < 0, c.jitter >= 1:
+		return ErrInvalidTTL
+	case c.interval < 0, c.interval == 0 && (c.done != nil || c.sink != nil):
+		return ErrInvalidJanitor
+	case c.clock == nil:
+		return ErrInvalidClock
+	}
+	return nil
+}
+
+func (c *config) build(capacity int) Interface {
+	base := New(capacity)
+	base.weigher = c.weigher
+	base.ttl = c.ttl
+	base.sliding = c.sliding
+	base.lifetime = c.lifetime
+	base.jitter = c.jitter
+	base.clock = c.clock
+	base.onEvict = c.onEvict
+	if c.policy != PolicyNone {
+		base.admission = c.admit
+	}
+	queueSize := capacity
+	if c.weigher != nil {
+		queueSize = DefaultLRUQueueSize
+	}
+	switch c.policy {
+	case PolicyFIFO:
+		return newFIFO(base)
+	case PolicyLRU:
+		return newLRU(base, queueSize)
+	case PolicyLFU:
+		return newLFU(base, queueSize)
+	case PolicyTTL:
+		return newTTL(base)
+	case PolicyCLOCK:
+		return newCLOCK(base)
+	default:
+		return base
+	}
+}
+
+// Build returns a cache configured by options.
+// Unlike NewCache it returns an error for invalid configurations.
+func Build(options ...Option) (Interface, error) {
+	c := config{
+		clock: SystemClock,
+	}
+	for _, option := range options {
+		option(&c)
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	var cache Interface
+	if c.shards > 1 {
+		shards := make([]Interface, c.shards)
+		for i := range shards {
+			shards[i] = c.build(shardSize(c.capacity, c.shards, i))
+		}
+		cache = NewSharded(shards...)
+	} else {
+		cache = c.build(c.capacity)
+	}
+	if c.interval > 0 {
+		janitor(cache, c.interval, c.clock, c.sink, c.done)
+	}
+	return cache, nil
+}
+
+// shardSize returns the capacity of shard i so that shard capacities add up to capacity.
+func shardSize(capacity, shards, i int) int {
+	size := capacity / shards
+	if i < capacity%shards {
+		size++
+	}
+	return size
+}
+
+type trimmer interface {
+	Trim(now time.Time) []interface{}
+}
+
+// janitor trims expired items and reports metrics every interval until done is closed.
+func janitor(c Interface, interval time.Duration, clock Clock, sink MetricsSink, done <-chan struct{}) {
+	last := c.Metrics()
+	var run func()
+	run = func() {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if t, ok := c.(trimmer); ok {
+			t.Trim(clock.Now())
+		}
+		if sink != nil {
+			m := c.Metrics()
+			sink.Observe(m.Delta(last))
+			last = m
+		}
+		clock.AfterFunc(interval, run)
+	}
+	clock.AfterFunc(interval, run)
+}
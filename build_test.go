@@ -0,0 +1,173 @@
+package cache_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+	"github.com/alxarch/go-cache/cachetest"
+)
+
+func Test_Build(t *testing.T) {
+	for _, tc := range []struct {
+		options []cache.Option
+		err     error
+	}{
+		{[]cache.Option{cache.WithPolicy(cache.PolicyLRU)}, cache.ErrInvalidCapacity},
+		{[]cache.Option{cache.WithCapacity(-1)}, cache.ErrInvalidCapacity},
+		{[]cache.Option{cache.WithWeigher(func(k, v interface{}) int { return 1 })}, cache.ErrInvalidCapacity},
+		{[]cache.Option{cache.WithPolicy("MRU"), cache.WithCapacity(10)}, cache.ErrInvalidPolicy},
+		{[]cache.Option{cache.WithShards(-1)}, cache.ErrInvalidShards},
+		{[]cache.Option{cache.WithShards(11), cache.WithCapacity(10)}, cache.ErrInvalidShards},
+		{[]cache.Option{cache.WithDefaultTTL(-time.Second)}, cache.ErrInvalidTTL},
+		{[]cache.Option{cache.WithJanitor(-time.Second, nil)}, cache.ErrInvalidJanitor},
+		{[]cache.Option{cache.WithMetricsSink(cache.MetricsSinkFunc(func(cache.Metrics) {}))}, cache.ErrInvalidJanitor},
+		{[]cache.Option{cache.WithClock(nil)}, cache.ErrInvalidClock},
+	} {
+		if c, err := cache.Build(tc.options...); err != tc.err || c != nil {
+			t.Errorf("Invalid error %v != %v", err, tc.err)
+		}
+	}
+	for policy, typ := range map[cache.EvictionPolicy]string{
+		cache.PolicyNone: "*cache.Cache",
+		cache.PolicyFIFO: "*cache.FIFO",
+		cache.PolicyLRU:  "*cache.LRU",
+		cache.PolicyLFU:  "*cache.LFU",
+		cache.PolicyTTL:  "*cache.TTL",
+	} {
+		c, err := cache.Build(cache.WithPolicy(policy), cache.WithCapacity(10))
+		if err != nil {
+			t.Errorf("Unexpected error %s", err)
+		} else if fmt.Sprintf("%T", c) != typ {
+			t.Errorf("Invalid type %T", c)
+		}
+	}
+}
+
+func Test_BuildWeigher(t *testing.T) {
+	var evicted []interface{}
+	c, err := cache.Build(
+		cache.WithPolicy(cache.PolicyLRU),
+		cache.WithCapacity(10),
+		cache.WithWeigher(func(k, v interface{}) int { return len(v.(string)) }),
+		cache.WithOnEvict(func(k, v interface{}, reason cache.EvictReason) {
+			if reason != cache.ReasonCapacity {
+				t.Errorf("Invalid reason %s", reason)
+			}
+			evicted = append(evicted, k)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lru := c.(*cache.LRU)
+	lru.Set("foo", "1234", cache.Never())
+	lru.Set("bar", "1234", cache.Never())
+	if w := lru.Weight(); w != 8 {
+		t.Errorf("Invalid weight %d", w)
+	}
+	lru.Set("baz", "12345", cache.Never())
+	// New items are pushed to the back of the LRU list so the last one set is evicted
+	if w := lru.Weight(); w != 9 || len(evicted) != 1 || evicted[0] != "bar" {
+		t.Errorf("Invalid weight %d evicted %v", w, evicted)
+	}
+	if err := lru.Set("qux", "12345678901", cache.Never()); err != cache.ErrMaxSize {
+		t.Errorf("Invalid error %v", err)
+	}
+}
+
+func Test_BuildWeigherOversized(t *testing.T) {
+	for _, policy := range policies[1:] {
+		c, err := cache.Build(
+			cache.WithPolicy(policy),
+			cache.WithCapacity(10),
+			cache.WithWeigher(func(k, v interface{}) int { return len(v.(string)) }),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 5; i++ {
+			c.Set(i, "12", cache.Never())
+		}
+		// Items that can never fit do not evict anything
+		if err := c.Set("foo", "1234567890123", cache.Never()); err != cache.ErrMaxSize {
+			t.Errorf("%s: Invalid error %v", policy, err)
+		}
+		if m := c.Metrics(); m.Items != 5 || m.EvictCapacity != 0 {
+			t.Errorf("%s: Invalid metrics %#v", policy, m)
+		}
+		ns := c.(interface {
+			Namespace(name string, options ...cache.NamespaceOption) *cache.Namespace
+		}).Namespace("ns", cache.WithQuota(0, 4))
+		ns.Set("bar", "12", cache.Never())
+		if err := ns.Set("baz", "12345", cache.Never()); err != cache.ErrMaxSize {
+			t.Errorf("%s: Invalid error %v", policy, err)
+		}
+		if _, _, err := ns.Get("bar"); err != nil {
+			t.Errorf("%s: Namespace item evicted %v", policy, err)
+		}
+	}
+}
+
+func Test_BuildDefaultTTL(t *testing.T) {
+	now := time.Now()
+	var reasons []cache.EvictReason
+	c, err := cache.Build(
+		cache.WithPolicy(cache.PolicyTTL),
+		cache.WithCapacity(10),
+		cache.WithDefaultTTL(time.Minute),
+		cache.WithClock(cachetest.NewClock(now)),
+		cache.WithOnEvict(func(k, v interface{}, reason cache.EvictReason) {
+			reasons = append(reasons, reason)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set("foo", "bar", time.Time{})
+	c.Set("bar", "baz", cache.Never())
+	c.Set("baz", "foo", now.Add(time.Hour))
+	if _, exp, _ := c.Get("foo"); !exp.Equal(now.Add(time.Minute)) {
+		t.Errorf("Invalid default exp %s", exp)
+	}
+	if _, exp, _ := c.Get("bar"); !exp.IsZero() {
+		t.Errorf("Invalid never exp %s", exp)
+	}
+	if _, exp, _ := c.Get("baz"); !exp.Equal(now.Add(time.Hour)) {
+		t.Errorf("Invalid exp %s", exp)
+	}
+	c.(*cache.TTL).Trim(now.Add(2 * time.Minute))
+	c.Evict("bar")
+	if len(reasons) != 2 || reasons[0] != cache.ReasonExpired || reasons[1] != cache.ReasonExplicit {
+		t.Errorf("Invalid reasons %v", reasons)
+	}
+}
+
+func Test_BuildJanitor(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	deltas := make(chan cache.Metrics, 100)
+	c, err := cache.Build(
+		cache.WithJanitor(time.Millisecond, done),
+		cache.WithMetricsSink(cache.MetricsSinkFunc(func(delta cache.Metrics) {
+			deltas <- delta
+		})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Set("foo", "bar", time.Now().Add(-time.Second))
+	timeout := time.After(5 * time.Second)
+	for expired := uint64(0); expired == 0; {
+		select {
+		case d := <-deltas:
+			expired += d.Expired
+		case <-timeout:
+			t.Fatal("Timeout")
+		}
+	}
+	if n := c.Evict(); n != 0 {
+		t.Errorf("Expired items not trimmed %d", n)
+	}
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"errors"
+	"math/rand/v2"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -27,18 +28,80 @@ type Interface interface {
 	Metrics() Metrics
 }
 
-// Never is a helper that returns a nil expiration time.
+// never is the sentinel expiration time returned by Never.
+var never = time.Unix(1<<62, 0)
+
+// Never is a helper that returns an expiration time for items that never expire.
+// It differs from a zero expiration time which is replaced with the default TTL of caches that have one.
+// Items set with Never are reported with a zero expiration time by caches and Proxy.
+// Never itself is a time about 146 billion years from now, Upstreams used without a cache
+// such as Blocking pass it on unchanged.
 func Never() time.Time {
-	return time.Time{}
+	return never
 }
 
+// Exp returns an expiration time ttl from now.
+// Use the Exp method of caches to respect their Clock.
 func Exp(ttl time.Duration) time.Time {
 	return time.Now().Add(ttl)
 }
 
 type entry struct {
-	value interface{}
-	exp   time.Time
+	key    interface{}
+	value  interface{}
+	exp    time.Time
+	weight int
+	// limit caps the expiration of items in caches with a max lifetime.
+	limit time.Time
+	tags  []interface{}
+	// ref is the reference bit of caches with a CLOCK policy, accessed atomically
+	ref uint32
+	// index is the position of the entry in the expiry heap
+	index int
+}
+
+// reference sets the reference bit avoiding writes to entries that are already referenced.
+func (e *entry) reference() {
+	if atomic.LoadUint32(&e.ref) == 0 {
+		atomic.StoreUint32(&e.ref, 1)
+	}
+}
+
+// EvictReason is the reason an item was removed from a cache.
+type EvictReason int
+
+const (
+	// ReasonExplicit items were removed by a call to Evict.
+	ReasonExplicit EvictReason = iota
+	// ReasonCapacity items were removed to make room for new ones.
+	ReasonCapacity
+	// ReasonExpired items were removed by Trim because they expired or belong to an older generation.
+	ReasonExpired
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case ReasonExplicit:
+		return "explicit"
+	case ReasonCapacity:
+		return "capacity"
+	case ReasonExpired:
+		return "expired"
+	default:
+		return "unknown"
+	}
+}
+
+// EvictHook is called for each item removed from a cache.
+// Hooks are called after the cache is unlocked but must not call back into the cache.
+type EvictHook func(key, value interface{}, reason EvictReason)
+
+// Weigher returns the weight of an item counted against the capacity of a cache.
+type Weigher func(key, value interface{}) int
+
+type removed struct {
+	key, value interface{}
+	reason     EvictReason
 }
 
 // Cache implements Interface.
@@ -46,8 +109,36 @@ type entry struct {
 type Cache struct {
 	values  map[interface{}]*entry
 	maxsize int
+	weight  int
+	weigher Weigher
+	ttl     time.Duration
+	// Get extends expiration by ttl up to lifetime after Set
+	sliding  bool
+	lifetime time.Duration
+	// jitter is the max fraction of the TTL removed from expiration times
+	jitter  float64
+	clock   Clock
+	onEvict EvictHook
+	// Items removed while locked, passed to onEvict on unlock
+	removed []removed
 	mu      sync.RWMutex
 	metrics Metrics
+	// policy is the eviction policy wrapping the cache, if any
+	policy policy
+	// tags indexes keys by tag
+	tags map[interface{}]map[interface{}]struct{}
+	// gens holds the current generation of namespaces,
+	// stale is set when a generation is bumped until the next Trim
+	gens  map[interface{}]uint64
+	stale bool
+	// expiry orders entries that expire so that Trim only visits due entries
+	expiry expiryHeap
+	// namespaces holds quotas and metrics of Namespace views
+	namespaces map[string]*namespace
+	// refs is set if hits mark the reference bit of entries
+	refs bool
+	// admission decides whether new keys may evict items of a full cache
+	admission Admission
 }
 
 // New returns a new Cache.
@@ -61,19 +152,105 @@ func New(size int) *Cache {
 	return &Cache{
 		values:  make(map[interface{}]*entry, size),
 		maxsize: size,
+		clock:   SystemClock,
 	}
 }
 
+// expires resolves the expiration time of an item.
+// Zero expiration times are replaced by the default TTL and Never by a zero time.
+// Caches with jitter shorten expiration times by a random fraction of their TTL.
+func (c *Cache) expires(exp time.Time) time.Time {
+	switch {
+	case exp.IsZero():
+		if c.ttl <= 0 {
+			return exp
+		}
+		now := c.clock.Now()
+		return jitter(now.Add(c.ttl), now, c.jitter)
+	case exp.Equal(never):
+		return time.Time{}
+	case c.jitter > 0:
+		return jitter(exp, c.clock.Now(), c.jitter)
+	}
+	return exp
+}
+
+// jitter shortens the time from now to exp by a random fraction up to fraction.
+func jitter(exp, now time.Time, fraction float64) time.Time {
+	if fraction <= 0 {
+		return exp
+	}
+	if ttl := exp.Sub(now); ttl > 0 {
+		return exp.Add(-time.Duration(rand.Float64() * fraction * float64(ttl)))
+	}
+	return exp
+}
+
 // Set assigns a value to a key and sets the expiration time
 // If the size limit is reached it returns MaxSizeError.
 func (c *Cache) Set(k, v interface{}, exp time.Time) error {
+	return c.set(k, v, c.expires(exp))
+}
+
+func (c *Cache) set(k, v interface{}, exp time.Time) error {
+	w := c.weigh(k, v)
 	c.mu.Lock()
-	if _, ok := c.values[k]; !ok && c.maxsize > 0 && len(c.values) >= c.maxsize {
-		c.mu.Unlock()
+	err := c.insert(k, v, exp, w)
+	c.mu.Unlock()
+	return err
+}
+
+func (c *Cache) weigh(k, v interface{}) int {
+	if c.weigher != nil {
+		return c.weigher(k, v)
+	}
+	return 1
+}
+
+// oversized reports whether an item weighs more than the capacity so it never fits.
+func (c *Cache) oversized(k, v interface{}) bool {
+	if c.weigher == nil {
+		return false
+	}
+	c.mu.RLock()
+	max := c.maxsize
+	c.mu.RUnlock()
+	return max > 0 && c.weigh(k, v) > max
+}
+
+// insert stores an entry, the caller must hold the lock.
+func (c *Cache) insert(k, v interface{}, exp time.Time, w int) error {
+	weight := c.weight + w
+	old := c.values[k]
+	if old != nil {
+		weight -= old.weight
+	}
+	if c.maxsize > 0 && weight > c.maxsize {
 		return ErrMaxSize
 	}
-	c.values[k] = &entry{v, exp}
-	c.mu.Unlock()
+	var limit time.Time
+	if c.lifetime > 0 {
+		limit = c.clock.Now().Add(c.lifetime)
+		if !exp.IsZero() && exp.After(limit) {
+			exp = limit
+		}
+	}
+	dw, items := w, int64(1)
+	if old != nil {
+		if old.tags != nil {
+			c.untag(k, old.tags)
+		}
+		c.unschedule(old)
+		dw, items = w-old.weight, 0
+	}
+	e := &entry{key: k, value: v, exp: exp, weight: w, limit: limit, index: -1}
+	c.values[k] = e
+	c.schedule(e)
+	c.weight = weight
+	atomic.AddUint64(&c.metrics.Set, 1)
+	if ns := c.account(k, dw, items); ns != nil {
+		atomic.AddUint64(&ns.metrics.Set, 1)
+	}
 	return nil
 }
 
@@ -81,6 +258,12 @@ func (c *Cache) Set(k, v interface{}, exp time.Time) error {
 // If a key does not exist in cache KeyError is returned.
 // If a key is expired ExpiredError is returned
 func (c *Cache) Get(k interface{}) (v interface{}, exp time.Time, err error) {
+	if c.admission != nil {
+		c.admission.Record(k)
+	}
+	if c.sliding {
+		return c.slide(k)
+	}
 	c.mu.RLock()
 	e, ok := c.values[k]
 	if !ok {
@@ -89,18 +272,69 @@ func (c *Cache) Get(k interface{}) (v interface{}, exp time.Time, err error) {
 		err = ErrKeyNotFound
 		return
 	}
-	c.mu.RUnlock()
 	v, exp = e.value, e.exp
+	c.mu.RUnlock()
+
+	if exp.IsZero() || exp.After(c.clock.Now()) {
+		if c.refs {
+			e.reference()
+		}
+		atomic.AddUint64(&c.metrics.Hit, 1)
+		return
+	}
+	err = ErrExpired
+	atomic.AddUint64(&c.metrics.Miss, 1)
+	return
+}
 
-	if exp.IsZero() || exp.After(time.Now()) {
+// slide is Get for caches with sliding expiration.
+// Fresh items have their expiration extended by the default TTL up to their lifetime limit.
+func (c *Cache) slide(k interface{}) (v interface{}, exp time.Time, err error) {
+	now := c.clock.Now()
+	c.mu.Lock()
+	e, ok := c.values[k]
+	if !ok {
+		c.mu.Unlock()
+		atomic.AddUint64(&c.metrics.Miss, 1)
+		err = ErrKeyNotFound
+		return
+	}
+	v, exp = e.value, e.exp
+	if c.refs && (exp.IsZero() || exp.After(now)) {
+		e.reference()
+	}
+	if exp.IsZero() {
+		c.mu.Unlock()
+		atomic.AddUint64(&c.metrics.Hit, 1)
+		return
+	}
+	if exp.After(now) {
+		exp = now.Add(c.ttl)
+		if !e.limit.IsZero() && exp.After(e.limit) {
+			exp = e.limit
+		}
+		e.exp = exp
+		c.schedule(e)
+		c.mu.Unlock()
 		atomic.AddUint64(&c.metrics.Hit, 1)
 		return
 	}
+	c.mu.Unlock()
 	err = ErrExpired
 	atomic.AddUint64(&c.metrics.Miss, 1)
 	return
 }
 
+// Clock returns the clock used by the cache.
+func (c *Cache) Clock() Clock {
+	return c.clock
+}
+
+// Exp returns an expiration time ttl from now according to the cache clock.
+func (c *Cache) Exp(ttl time.Duration) time.Time {
+	return c.clock.Now().Add(ttl)
+}
+
 // Size returns size of all keys in cache both expired and fresh
 func (c *Cache) Size() (n int) {
 	c.mu.RLock()
@@ -110,29 +344,84 @@ func (c *Cache) Size() (n int) {
 }
 
 // Size returns size of all keys in cache both expired and fresh
-func (c *Cache) Cap() int {
-	return c.maxsize
+func (c *Cache) Cap() (n int) {
+	c.mu.RLock()
+	n = c.maxsize
+	c.mu.RUnlock()
+	return
+}
+
+// Weight returns the total weight of items in cache.
+// Without a Weigher each item weighs 1.
+func (c *Cache) Weight() (w int) {
+	c.mu.RLock()
+	w = c.weight
+	c.mu.RUnlock()
+	return
 }
 
-// Trim removes all expired keys and returns a slice of removed keys
+// Trim removes all expired keys and keys of older generations and returns a slice of removed keys
+// Expired keys are taken from an index ordered by expiration so only due entries are visited.
+// Keys of older generations are collected by a full scan on the first Trim after a Bump.
 func (c *Cache) Trim(now time.Time) (expired []interface{}) {
 	expired = make([]interface{}, 0, 64)
 	c.mu.Lock()
-	for k, e := range c.values {
-		if !e.exp.IsZero() && e.exp.Before(now) {
-			delete(c.values, k)
-			expired = append(expired, k)
+	for len(c.expiry) > 0 && c.expiry[0].exp.Before(now) {
+		e := c.expiry[0]
+		c.remove(e.key, e, ReasonExpired)
+		expired = append(expired, e.key)
+	}
+	if c.stale {
+		c.stale = false
+		for k, e := range c.values {
+			if c.isStale(k) {
+				c.remove(k, e, ReasonExpired)
+				expired = append(expired, k)
+			}
 		}
 	}
-	c.mu.Unlock()
+	c.unlock()
 	atomic.AddUint64(&c.metrics.Expired, uint64(len(expired)))
 	return expired
 }
 
-func (c *Cache) evict(keys []interface{}) (n int) {
+// remove deletes an entry, the caller must hold the lock and release it with unlock.
+func (c *Cache) remove(k interface{}, e *entry, reason EvictReason) {
+	delete(c.values, k)
+	c.unschedule(e)
+	c.weight -= e.weight
+	if e.tags != nil {
+		c.untag(k, e.tags)
+	}
+	if ns := c.account(k, -e.weight, -1); ns != nil {
+		switch reason {
+		case ReasonExplicit:
+			atomic.AddUint64(&ns.metrics.EvictExplicit, 1)
+		case ReasonCapacity:
+			atomic.AddUint64(&ns.metrics.EvictCapacity, 1)
+		case ReasonExpired:
+			atomic.AddUint64(&ns.metrics.Expired, 1)
+		}
+	}
+	if c.onEvict != nil {
+		c.removed = append(c.removed, removed{k, e.value, reason})
+	}
+}
+
+// unlock releases the lock and passes items removed while locked to the eviction hook.
+func (c *Cache) unlock() {
+	items := c.removed
+	c.removed = nil
+	c.mu.Unlock()
+	for _, r := range items {
+		c.onEvict(r.key, r.value, r.reason)
+	}
+}
+
+func (c *Cache) evict(keys []interface{}, reason EvictReason) (n int) {
 	for _, k := range keys {
-		if _, ok := c.values[k]; ok {
-			delete(c.values, k)
+		if e, ok := c.values[k]; ok {
+			c.remove(k, e, reason)
 			n++
 		}
 	}
@@ -142,37 +431,35 @@ func (c *Cache) evict(keys []interface{}) (n int) {
 // Evict removes items from the cache. It returns the new cache size.
 func (c *Cache) Evict(keys ...interface{}) (size int) {
 	c.mu.Lock()
-	c.metrics.Evict += uint64(c.evict(keys))
+	atomic.AddUint64(&c.metrics.EvictExplicit, uint64(c.evict(keys, ReasonExplicit)))
 	size = len(c.values)
-	c.mu.Unlock()
+	c.unlock()
 	return
 }
 
-type Metrics struct {
-	Hit, Miss, Evict, Expired, Items uint64
-}
-
-func (c *Cache) Metrics() (m Metrics) {
-	m.Hit = atomic.LoadUint64(&c.metrics.Hit)
-	m.Miss = atomic.LoadUint64(&c.metrics.Miss)
-	c.mu.RLock()
-	m.Evict = c.metrics.Evict
-	m.Expired = c.metrics.Expired
-	m.Items = uint64(len(c.values))
-	c.mu.RUnlock()
-	return
+// discard removes items to make room for new ones.
+// It is used by eviction policies so that metrics can tell capacity evictions from explicit ones.
+func (c *Cache) discard(keys ...interface{}) {
+	c.mu.Lock()
+	atomic.AddUint64(&c.metrics.EvictCapacity, uint64(c.evict(keys, ReasonCapacity)))
+	c.unlock()
 }
 
 type EvictionPolicy string
 
 const (
-	PolicyNone EvictionPolicy = ""
-	PolicyFIFO EvictionPolicy = "FIFO"
-	PolicyLRU  EvictionPolicy = "LRU"
-	PolicyLFU  EvictionPolicy = "LFU"
-	PolicyTTL  EvictionPolicy = "TTL"
+	PolicyNone  EvictionPolicy = ""
+	PolicyFIFO  EvictionPolicy = "FIFO"
+	PolicyLRU   EvictionPolicy = "LRU"
+	PolicyLFU   EvictionPolicy = "LFU"
+	PolicyTTL   EvictionPolicy = "TTL"
+	PolicyCLOCK EvictionPolicy = "CLOCK"
 )
 
+// NewCache returns a cache with an eviction policy.
+// If size is zero or less it silently falls back to a Cache without size limit.
+//
+// Deprecated: Use Build which reports invalid configurations.
 func NewCache(size int, policy EvictionPolicy) Interface {
 	if size <= 0 {
 		return New(0)
@@ -186,6 +473,8 @@ func NewCache(size int, policy EvictionPolicy) Interface {
 		return NewLFU(size)
 	case PolicyTTL:
 		return NewTTL(size)
+	case PolicyCLOCK:
+		return NewCLOCK(size)
 	default:
 		return New(size)
 	}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	cache "github.com/alxarch/go-cache"
+	"github.com/alxarch/go-cache/cachetest"
 )
 
 func Test_Cache(t *testing.T) {
@@ -34,6 +35,16 @@ func Test_Cache(t *testing.T) {
 	}
 }
 
+// policies lists all eviction policies starting with PolicyNone, tests range over policies[1:] to skip it.
+var policies = []cache.EvictionPolicy{
+	cache.PolicyNone,
+	cache.PolicyFIFO,
+	cache.PolicyLRU,
+	cache.PolicyLFU,
+	cache.PolicyTTL,
+	cache.PolicyCLOCK,
+}
+
 func Test_Factory(t *testing.T) {
 	c := cache.NewCache(100, "")
 	if c, ok := c.(*cache.Cache); !ok {
@@ -65,4 +76,52 @@ func Test_Factory(t *testing.T) {
 	} else if c.Cap() != 100 {
 		t.Errorf("Invalid size %d", c.Cap())
 	}
+	c = cache.NewCache(100, cache.PolicyCLOCK)
+	if c, ok := c.(*cache.CLOCK); !ok {
+		t.Errorf("Invalid cache type")
+	} else if c.Cap() != 100 {
+		t.Errorf("Invalid size %d", c.Cap())
+	}
+}
+
+func Test_SlidingTTL(t *testing.T) {
+	if _, err := cache.Build(cache.WithSlidingTTL(time.Minute, time.Second)); err != cache.ErrInvalidTTL {
+		t.Errorf("Invalid error %v", err)
+	}
+	for _, policy := range policies {
+		clock := cachetest.NewClock(time.Now())
+		c, err := cache.Build(
+			cache.WithPolicy(policy),
+			cache.WithCapacity(10),
+			cache.WithClock(clock),
+			cache.WithSlidingTTL(10*time.Minute, 30*time.Minute),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		start := clock.Now()
+		c.Set("foo", "bar", time.Time{})
+		c.Set("bar", "baz", cache.Never())
+		c.Set("baz", "foo", start.Add(time.Hour))
+		if _, exp, _ := c.Get("baz"); !exp.Equal(start.Add(10 * time.Minute)) {
+			t.Errorf("%s: Invalid exp %s", policy, exp)
+		}
+		for i := 0; i < 2; i++ {
+			clock.Advance(9 * time.Minute)
+			if _, exp, err := c.Get("foo"); err != nil || !exp.Equal(clock.Now().Add(10*time.Minute)) {
+				t.Errorf("%s: Invalid sliding exp %s %v", policy, exp, err)
+			}
+		}
+		clock.Advance(5 * time.Minute)
+		if _, exp, err := c.Get("foo"); err != nil || !exp.Equal(start.Add(30*time.Minute)) {
+			t.Errorf("%s: Invalid max lifetime exp %s %v", policy, exp, err)
+		}
+		clock.Advance(8 * time.Minute)
+		if _, _, err := c.Get("foo"); err != cache.ErrExpired {
+			t.Errorf("%s: Invalid error %v", policy, err)
+		}
+		if _, exp, err := c.Get("bar"); err != nil || !exp.IsZero() {
+			t.Errorf("%s: Invalid never exp %s %v", policy, exp, err)
+		}
+	}
 }
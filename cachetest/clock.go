@@ -0,0 +1,117 @@
+// Package cachetest provides utilities for testing code that uses caches.
+package cachetest
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+// Clock is a cache.Clock that only moves when advanced manually.
+type Clock struct {
+	mu     sync.Mutex
+	now    time.Time
+	seq    int
+	timers []*timer
+}
+
+var _ cache.Clock = (*Clock)(nil)
+
+// NewClock returns a Clock set to now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now implements cache.Clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc implements cache.Clock.
+// Functions are called synchronously by Advance.
+func (c *Clock) AfterFunc(d time.Duration, f func()) cache.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	t := &timer{clock: c, at: c.now.Add(d), seq: c.seq, f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Pending returns the number of pending timers.
+func (c *Clock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// Advance moves the clock forward by d firing due timers in order.
+// While a timer fires the clock is set to its deadline.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	until := c.now.Add(d)
+	c.mu.Unlock()
+	c.AdvanceTo(until)
+}
+
+// AdvanceTo moves the clock forward to t firing due timers in order.
+func (c *Clock) AdvanceTo(t time.Time) {
+	for {
+		c.mu.Lock()
+		next := c.next()
+		if next == nil || next.at.After(t) {
+			if t.After(c.now) {
+				c.now = t
+			}
+			c.mu.Unlock()
+			return
+		}
+		c.remove(next)
+		if next.at.After(c.now) {
+			c.now = next.at
+		}
+		c.mu.Unlock()
+		next.f()
+	}
+}
+
+func (c *Clock) next() *timer {
+	if len(c.timers) == 0 {
+		return nil
+	}
+	sort.Slice(c.timers, func(i, j int) bool {
+		a, b := c.timers[i], c.timers[j]
+		if a.at.Equal(b.at) {
+			return a.seq < b.seq
+		}
+		return a.at.Before(b.at)
+	})
+	return c.timers[0]
+}
+
+func (c *Clock) remove(t *timer) bool {
+	for i, p := range c.timers {
+		if p == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type timer struct {
+	clock *Clock
+	at    time.Time
+	seq   int
+	f     func()
+}
+
+func (t *timer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.remove(t)
+}
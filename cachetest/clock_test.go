@@ -0,0 +1,45 @@
+package cachetest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alxarch/go-cache/cachetest"
+)
+
+func Test_Clock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := cachetest.NewClock(start)
+	var fired []time.Time
+	record := func() {
+		fired = append(fired, c.Now())
+	}
+	c.AfterFunc(2*time.Second, record)
+	c.AfterFunc(time.Second, func() {
+		record()
+		c.AfterFunc(time.Second, record)
+	})
+	stopped := c.AfterFunc(time.Second, record)
+	if !stopped.Stop() {
+		t.Error("Timer not pending")
+	}
+	if stopped.Stop() {
+		t.Error("Timer stopped twice")
+	}
+	c.Advance(500 * time.Millisecond)
+	if len(fired) != 0 || !c.Now().Equal(start.Add(500*time.Millisecond)) {
+		t.Errorf("Invalid state %v %s", fired, c.Now())
+	}
+	c.Advance(10 * time.Second)
+	if len(fired) != 3 || c.Pending() != 0 {
+		t.Fatalf("Invalid fired timers %v", fired)
+	}
+	for i, d := range []time.Duration{time.Second, 2 * time.Second, 2 * time.Second} {
+		if !fired[i].Equal(start.Add(d)) {
+			t.Errorf("Timer %d fired at %s", i, fired[i])
+		}
+	}
+	if !c.Now().Equal(start.Add(10500 * time.Millisecond)) {
+		t.Errorf("Invalid now %s", c.Now())
+	}
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock tells the current time to caches and schedules their background work.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f after duration d.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a pending call scheduled by a Clock.
+type Timer interface {
+	// Stop prevents the call from happening and reports whether it was pending.
+	Stop() bool
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// SystemClock is a Clock using time.Now.
+var SystemClock Clock = systemClock{}
+
+type coarseClock struct {
+	now int64
+}
+
+// NewCoarseClock returns a Clock that updates the current time every resolution until done is closed.
+// It avoids the overhead of time.Now on hot paths at the cost of precision.
+func NewCoarseClock(resolution time.Duration, done <-chan struct{}) Clock {
+	c := &coarseClock{now: time.Now().UnixNano()}
+	go func() {
+		ticker := time.NewTicker(resolution)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				atomic.StoreInt64(&c.now, now.UnixNano())
+			}
+		}
+	}()
+	return c
+}
+
+func (c *coarseClock) Now() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&c.now))
+}
+
+func (c *coarseClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// clockOf returns the clock of a cache or SystemClock if it does not expose one.
+func clockOf(c Interface) Clock {
+	if c, ok := c.(interface{ Clock() Clock }); ok {
+		return c.Clock()
+	}
+	return SystemClock
+}
@@ -0,0 +1,73 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+	"github.com/alxarch/go-cache/cachetest"
+)
+
+func Test_Clock(t *testing.T) {
+	clock := cachetest.NewClock(time.Now())
+	var deltas []cache.Metrics
+	c, err := cache.Build(
+		cache.WithPolicy(cache.PolicyLRU),
+		cache.WithCapacity(10),
+		cache.WithClock(clock),
+		cache.WithJanitor(time.Minute, nil),
+		cache.WithMetricsSink(cache.MetricsSinkFunc(func(delta cache.Metrics) {
+			deltas = append(deltas, delta)
+		})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lru := c.(*cache.LRU)
+	lru.Set("foo", "bar", lru.Exp(30*time.Second))
+	clock.Advance(20 * time.Second)
+	if _, _, err := lru.Get("foo"); err != nil {
+		t.Errorf("Unexpected error %s", err)
+	}
+	clock.Advance(20 * time.Second)
+	if _, _, err := lru.Get("foo"); err != cache.ErrExpired {
+		t.Errorf("Invalid error %v", err)
+	}
+	if len(deltas) != 0 || lru.Size() != 1 {
+		t.Errorf("Janitor ran early")
+	}
+	clock.Advance(20 * time.Second)
+	if len(deltas) != 1 || deltas[0].Expired != 1 || deltas[0].Hit != 1 || lru.Size() != 0 {
+		t.Errorf("Invalid janitor run %v", deltas)
+	}
+	clock.Advance(time.Hour)
+	if len(deltas) != 61 {
+		t.Errorf("Invalid janitor runs %d", len(deltas))
+	}
+
+	p := cache.Proxy(cache.UpstreamFunc(func(x interface{}) (interface{}, time.Time, error) {
+		clock.Advance(30 * time.Millisecond)
+		return x, cache.Never(), nil
+	}), lru)
+	p.Get("bar")
+	if m := p.(interface{ Metrics() cache.Metrics }).Metrics(); m.LoadTime != 30*time.Millisecond || m.LoadLatency[4] != 1 {
+		t.Errorf("Invalid load metrics %v %v", m.LoadTime, m.LoadLatency)
+	}
+}
+
+func Test_CoarseClock(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	clock := cache.NewCoarseClock(time.Millisecond, done)
+	start := clock.Now()
+	if time.Since(start) > time.Second {
+		t.Errorf("Invalid start %s", start)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !clock.Now().After(start) {
+		if time.Now().After(deadline) {
+			t.Fatal("Clock not updated")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,188 @@
+package cache
+
+import (
+	"iter"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CLOCK implements Interface with a CLOCK (second chance) eviction policy approximating LRU.
+// Reads only set a reference bit on the item and never take the policy lock.
+// On eviction a hand sweeps the items clearing reference bits and evicts the first item without one.
+type CLOCK struct {
+	*Cache
+	ring  []interface{}
+	index map[interface{}]int
+	free  []int
+	hand  int
+	mu    sync.Mutex
+}
+
+// emptySlot marks ring slots of removed keys.
+type emptySlot struct{}
+
+func NewCLOCK(size int) *CLOCK {
+	if size <= 0 {
+		return nil
+	}
+	return newCLOCK(New(size))
+}
+
+func newCLOCK(c *Cache) *CLOCK {
+	cl := &CLOCK{
+		Cache: c,
+		index: make(map[interface{}]int),
+	}
+	c.refs = true
+	c.policy = cl
+	return cl
+}
+
+// Set assigns a value to a key and sets the expiration time.
+// If the size limit is reached the first unreferenced item found by the clock hand is evicted.
+func (c *CLOCK) Set(x, y interface{}, exp time.Time) (err error) {
+	exp = c.Cache.expires(exp)
+	c.mu.Lock()
+	err = c.setLocked(x, y, exp)
+	c.mu.Unlock()
+	return
+}
+
+func (c *CLOCK) setLocked(x, y interface{}, exp time.Time) (err error) {
+	admitted := false
+	for {
+		if err = c.Cache.set(x, y, exp); err != ErrMaxSize {
+			if err == nil {
+				c.track(x)
+			}
+			return
+		}
+		k, ok := c.victim()
+		if !ok {
+			return
+		}
+		if !admitted {
+			if err = c.Cache.admit(x, y, k); err != nil {
+				return
+			}
+			admitted = true
+		}
+		c.forgetLocked(k)
+		c.Cache.discard(k)
+	}
+}
+
+// track adds a key to the ring reusing free slots.
+func (c *CLOCK) track(k interface{}) {
+	if _, ok := c.index[k]; ok {
+		return
+	}
+	if n := len(c.free); n > 0 {
+		i := c.free[n-1]
+		c.free = c.free[:n-1]
+		c.ring[i] = k
+		c.index[k] = i
+		return
+	}
+	c.index[k] = len(c.ring)
+	c.ring = append(c.ring, k)
+}
+
+// victim advances the hand to the first unreferenced key clearing reference bits on the way.
+func (c *CLOCK) victim() (interface{}, bool) {
+	// Two full turns clear all reference bits
+	for n := 2 * len(c.ring); n > 0; n-- {
+		k := c.ring[c.hand]
+		c.hand = (c.hand + 1) % len(c.ring)
+		if _, empty := k.(emptySlot); empty || c.Cache.protected(k) {
+			continue
+		}
+		if !c.Cache.unref(k) {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+func (c *CLOCK) Evict(keys ...interface{}) int {
+	c.mu.Lock()
+	n := c.evictLocked(keys)
+	c.mu.Unlock()
+	return n
+}
+
+func (c *CLOCK) evictLocked(keys []interface{}) int {
+	for _, k := range keys {
+		c.forgetLocked(k)
+	}
+	return c.Cache.Evict(keys...)
+}
+
+func (c *CLOCK) lockPolicy()   { c.mu.Lock() }
+func (c *CLOCK) unlockPolicy() { c.mu.Unlock() }
+
+// Ordered returns an iterator over a snapshot of the items in the order the clock hand would evict them.
+func (c *CLOCK) Ordered() iter.Seq2[interface{}, Item] {
+	return c.Cache.ordered()
+}
+
+func (c *CLOCK) orderLocked() []interface{} {
+	keys := make([]interface{}, 0, len(c.index))
+	var referenced []interface{}
+	for i := range c.ring {
+		k := c.ring[(c.hand+i)%len(c.ring)]
+		if _, empty := k.(emptySlot); empty {
+			continue
+		}
+		if c.Cache.referenced(k) {
+			referenced = append(referenced, k)
+		} else {
+			keys = append(keys, k)
+		}
+	}
+	return append(keys, referenced...)
+}
+
+func (c *CLOCK) forgetLocked(k interface{}) {
+	if i, ok := c.index[k]; ok {
+		c.ring[i] = emptySlot{}
+		c.free = append(c.free, i)
+		delete(c.index, k)
+	}
+}
+
+func (c *CLOCK) resetLocked() {
+	c.ring, c.free, c.hand = nil, nil, 0
+	c.index = make(map[interface{}]int)
+}
+
+func (c *CLOCK) Trim(now time.Time) []interface{} {
+	c.mu.Lock()
+	expired := c.Cache.Trim(now)
+	for _, k := range expired {
+		c.forgetLocked(k)
+	}
+	c.mu.Unlock()
+	return expired
+}
+
+// unref clears the reference bit of a key and reports whether it was set.
+func (c *Cache) unref(k interface{}) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if e := c.values[k]; e != nil {
+		return atomic.SwapUint32(&e.ref, 0) == 1
+	}
+	return false
+}
+
+// referenced reports whether the reference bit of a key is set.
+func (c *Cache) referenced(k interface{}) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if e := c.values[k]; e != nil {
+		return atomic.LoadUint32(&e.ref) == 1
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+func Test_CLOCK(t *testing.T) {
+	if c := cache.NewCLOCK(0); c != nil {
+		t.Error("Returns nil on zero size")
+	}
+	c := cache.NewCLOCK(3)
+	c.Set("foo", 1, time.Time{})
+	c.Set("bar", 2, time.Time{})
+	c.Set("baz", 3, time.Time{})
+	c.Get("foo")
+	c.Get("baz")
+	// bar is the only item without a second chance
+	c.Set("qux", 4, time.Time{})
+	if _, _, err := c.Get("bar"); err != cache.ErrKeyNotFound {
+		t.Errorf("Invalid error %v", err)
+	}
+	// The hand cleared the reference bits of foo and baz so foo is next
+	c.Set("bar", 2, time.Time{})
+	if _, _, err := c.Peek("foo"); err != cache.ErrKeyNotFound {
+		t.Errorf("Invalid error %v", err)
+	}
+	if ks := keys(c.Ordered()); len(ks) != 3 {
+		t.Errorf("Invalid order %v", ks)
+	}
+	if m := c.Metrics(); m.EvictCapacity != 2 || m.Items != 3 {
+		t.Errorf("Invalid metrics %#v", m)
+	}
+	c.Evict("qux")
+	c.Set("expired", true, c.Exp(-time.Second))
+	if expired := c.Trim(time.Now()); len(expired) != 1 {
+		t.Errorf("Invalid trim %v", expired)
+	}
+	if n := c.Evict("bar", "baz"); n != 0 {
+		t.Errorf("Invalid size %d", n)
+	}
+}
+
+func benchmarkParallelGet(b *testing.B, c cache.Interface) {
+	for i := 0; i < 1000; i++ {
+		c.Set(i, i, time.Time{})
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			// Mostly hot keys with some misses that cause evictions
+			k := i % 100
+			if i%16 == 0 {
+				k = i
+			}
+			if _, _, err := c.Get(k); err != nil {
+				c.Set(k, k, time.Time{})
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkLRUParallel(b *testing.B) {
+	benchmarkParallelGet(b, cache.NewLRU(1000))
+}
+
+func BenchmarkCLOCKParallel(b *testing.B) {
+	benchmarkParallelGet(b, cache.NewCLOCK(1000))
+}
+
+func BenchmarkLFUParallel(b *testing.B) {
+	benchmarkParallelGet(b, cache.NewLFU(1000))
+}
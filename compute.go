@@ -0,0 +1,173 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// ComputeFunc computes the new value of a key from its current value.
+// exists is false if the key is not in cache or has expired.
+// The returned expiration time is handled as in Set.
+// If keep is false the key is removed from the cache.
+type ComputeFunc func(old interface{}, exists bool) (v interface{}, exp time.Time, keep bool)
+
+// updateFunc is a ComputeFunc that also receives the current expiration time
+// and can leave the entry untouched.
+type updateFunc func(old interface{}, exp time.Time, exists bool) (interface{}, time.Time, updateOp)
+
+type updateOp int
+
+const (
+	opNone updateOp = iota
+	opSet
+	// opKeepExp updates the value in place storing the returned expiration time as is
+	// and keeping the lifetime limit of the entry
+	opKeepExp
+	opDelete
+)
+
+// Compute atomically replaces the value of a key with the result of fn.
+// fn is called once with the cache locked and must not call back into the cache.
+// It returns the new value or nil if the key was removed.
+// If the new value does not fit in the cache it returns ErrMaxSize.
+func (c *Cache) Compute(k interface{}, fn ComputeFunc) (interface{}, error) {
+	return c.update(k, func(old interface{}, _ time.Time, exists bool) (interface{}, time.Time, updateOp) {
+		v, exp, keep := fn(old, exists)
+		if keep {
+			return v, exp, opSet
+		}
+		return nil, exp, opDelete
+	})
+}
+
+// update applies fn to a key and returns the resulting value.
+func (c *Cache) update(k interface{}, fn updateFunc) (interface{}, error) {
+	if p := c.policy; p != nil {
+		p.lockPolicy()
+		defer p.unlockPolicy()
+		c.mu.RLock()
+		old, exp, limit, exists := c.fresh(k)
+		c.mu.RUnlock()
+		v, exp, op := fn(old, exp, exists)
+		if op == opSet {
+			exp = c.expires(exp)
+		}
+		switch op {
+		case opSet, opKeepExp:
+			if err := p.setLocked(k, v, exp); err != nil {
+				return nil, err
+			}
+			if op == opKeepExp {
+				c.mu.Lock()
+				c.keepLimit(k, limit)
+				c.mu.Unlock()
+			}
+		case opDelete:
+			p.evictLocked([]interface{}{k})
+		}
+		return v, nil
+	}
+
+	c.mu.Lock()
+	old, exp, limit, exists := c.fresh(k)
+	v, exp, op := fn(old, exp, exists)
+	if op == opSet {
+		exp = c.expires(exp)
+	}
+	switch op {
+	case opSet, opKeepExp:
+		err := c.insert(k, v, exp, c.weigh(k, v))
+		if err == nil && op == opKeepExp {
+			c.keepLimit(k, limit)
+		}
+		c.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	case opDelete:
+		atomic.AddUint64(&c.metrics.EvictExplicit, uint64(c.evict([]interface{}{k}, ReasonExplicit)))
+		c.unlock()
+	default:
+		c.mu.Unlock()
+	}
+	return v, nil
+}
+
+// fresh returns the value, expiration time and lifetime limit of a key that has not expired.
+// The caller must hold the lock.
+func (c *Cache) fresh(k interface{}) (v interface{}, exp, limit time.Time, ok bool) {
+	e := c.values[k]
+	if e == nil {
+		return
+	}
+	if !e.exp.IsZero() && !e.exp.After(c.clock.Now()) {
+		return
+	}
+	return e.value, e.exp, e.limit, true
+}
+
+// keepLimit restores the lifetime limit of an entry updated in place.
+// The caller must hold the lock.
+func (c *Cache) keepLimit(k interface{}, limit time.Time) {
+	if e := c.values[k]; e != nil && !limit.IsZero() {
+		e.limit = limit
+	}
+}
+
+// GetOrSet returns the existing value of a key if present.
+// Otherwise it sets the key to v and returns v.
+// loaded is true if the value was already in cache.
+func (c *Cache) GetOrSet(k, v interface{}, exp time.Time) (actual interface{}, loaded bool, err error) {
+	actual, err = c.update(k, func(old interface{}, _ time.Time, exists bool) (interface{}, time.Time, updateOp) {
+		if exists {
+			loaded = true
+			return old, time.Time{}, opNone
+		}
+		return v, exp, opSet
+	})
+	return
+}
+
+// SetIfAbsent sets a key only if it is not in cache or has expired.
+// It reports whether the value was set.
+func (c *Cache) SetIfAbsent(k, v interface{}, exp time.Time) (ok bool, err error) {
+	_, loaded, err := c.GetOrSet(k, v, exp)
+	return err == nil && !loaded, err
+}
+
+// Replace sets a key only if it is in cache and has not expired.
+// It reports whether the value was replaced.
+func (c *Cache) Replace(k, v interface{}, exp time.Time) (ok bool, err error) {
+	_, err = c.update(k, func(_ interface{}, _ time.Time, exists bool) (interface{}, time.Time, updateOp) {
+		if !exists {
+			return nil, time.Time{}, opNone
+		}
+		ok = true
+		return v, exp, opSet
+	})
+	return ok && err == nil, err
+}
+
+// CompareAndSwap sets a key to v only if its current value equals old.
+// Values are compared with == so they must be comparable.
+// It reports whether the value was swapped.
+func (c *Cache) CompareAndSwap(k, old, v interface{}, exp time.Time) (ok bool, err error) {
+	_, err = c.update(k, func(cur interface{}, _ time.Time, exists bool) (interface{}, time.Time, updateOp) {
+		if !exists || cur != old {
+			return cur, time.Time{}, opNone
+		}
+		ok = true
+		return v, exp, opSet
+	})
+	return ok && err == nil, err
+}
+
+// GetAndDelete removes a key and returns its value.
+// ok is false if the key was not in cache or had expired.
+func (c *Cache) GetAndDelete(k interface{}) (v interface{}, ok bool) {
+	c.update(k, func(old interface{}, _ time.Time, exists bool) (interface{}, time.Time, updateOp) {
+		v, ok = old, exists
+		return nil, time.Time{}, opDelete
+	})
+	return
+}
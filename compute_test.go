@@ -0,0 +1,114 @@
+package cache_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+type computer interface {
+	cache.Interface
+	Compute(k interface{}, fn cache.ComputeFunc) (interface{}, error)
+	GetOrSet(k, v interface{}, exp time.Time) (interface{}, bool, error)
+	SetIfAbsent(k, v interface{}, exp time.Time) (bool, error)
+	Replace(k, v interface{}, exp time.Time) (bool, error)
+	CompareAndSwap(k, old, v interface{}, exp time.Time) (bool, error)
+	GetAndDelete(k interface{}) (interface{}, bool)
+}
+
+func Test_Compute(t *testing.T) {
+	for _, policy := range policies {
+		c, err := cache.Build(cache.WithCapacity(2), cache.WithPolicy(policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cc := c.(computer)
+		if v, loaded, err := cc.GetOrSet("foo", 1, time.Time{}); err != nil || loaded || v != 1 {
+			t.Errorf("%s: Invalid GetOrSet %v %v %v", policy, v, loaded, err)
+		}
+		if v, loaded, err := cc.GetOrSet("foo", 2, time.Time{}); err != nil || !loaded || v != 1 {
+			t.Errorf("%s: Invalid GetOrSet %v %v %v", policy, v, loaded, err)
+		}
+		if ok, err := cc.SetIfAbsent("foo", 2, time.Time{}); ok || err != nil {
+			t.Errorf("%s: Invalid SetIfAbsent %v %v", policy, ok, err)
+		}
+		if ok, err := cc.Replace("bar", 2, time.Time{}); ok || err != nil {
+			t.Errorf("%s: Invalid Replace %v %v", policy, ok, err)
+		}
+		if ok, err := cc.Replace("foo", 2, time.Time{}); !ok || err != nil {
+			t.Errorf("%s: Invalid Replace %v %v", policy, ok, err)
+		}
+		if ok, err := cc.CompareAndSwap("foo", 1, 3, time.Time{}); ok || err != nil {
+			t.Errorf("%s: Invalid CompareAndSwap %v %v", policy, ok, err)
+		}
+		if ok, err := cc.CompareAndSwap("foo", 2, 3, time.Time{}); !ok || err != nil {
+			t.Errorf("%s: Invalid CompareAndSwap %v %v", policy, ok, err)
+		}
+		if v, _, _ := cc.Get("foo"); v != 3 {
+			t.Errorf("%s: Invalid value %v", policy, v)
+		}
+		incr := func(old interface{}, exists bool) (interface{}, time.Time, bool) {
+			if !exists {
+				return 1, time.Time{}, true
+			}
+			return old.(int) + 1, time.Time{}, true
+		}
+		if v, err := cc.Compute("foo", incr); err != nil || v != 4 {
+			t.Errorf("%s: Invalid Compute %v %v", policy, v, err)
+		}
+		if v, err := cc.Compute("bar", incr); err != nil || v != 1 {
+			t.Errorf("%s: Invalid Compute %v %v", policy, v, err)
+		}
+		// Inserting a third key evicts one of the others unless there is no policy
+		_, err = cc.Compute("baz", incr)
+		if policy == cache.PolicyNone {
+			if err != cache.ErrMaxSize {
+				t.Errorf("%s: Invalid error %v", policy, err)
+			}
+		} else if err != nil || cc.Evict() != 2 {
+			t.Errorf("%s: Invalid Compute eviction %v %d", policy, err, cc.Evict())
+		} else if m := cc.Metrics(); m.EvictCapacity != 1 {
+			t.Errorf("%s: Invalid metrics %v", policy, m)
+		}
+		if v, err := cc.Compute("bar", func(interface{}, bool) (interface{}, time.Time, bool) {
+			return nil, time.Time{}, false
+		}); err != nil || v != nil {
+			t.Errorf("%s: Invalid Compute %v %v", policy, v, err)
+		}
+		if _, _, err := cc.Get("bar"); err != cache.ErrKeyNotFound {
+			t.Errorf("%s: Invalid error %v", policy, err)
+		}
+		cc.Set("qux", 5, time.Time{})
+		if v, ok := cc.GetAndDelete("qux"); !ok || v != 5 {
+			t.Errorf("%s: Invalid GetAndDelete %v %v", policy, v, ok)
+		}
+		if v, ok := cc.GetAndDelete("qux"); ok || v != nil {
+			t.Errorf("%s: Invalid GetAndDelete %v %v", policy, v, ok)
+		}
+	}
+}
+
+func Test_ComputeConcurrent(t *testing.T) {
+	c := cache.NewLRU(10)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Compute("foo", func(old interface{}, exists bool) (interface{}, time.Time, bool) {
+					if !exists {
+						return 1, time.Time{}, true
+					}
+					return old.(int) + 1, time.Time{}, true
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _, _ := c.Get("foo"); v != 800 {
+		t.Errorf("Invalid value %v", v)
+	}
+}
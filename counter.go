@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotNumber is returned by Incr and IncrFloat if the value of a key is not of the expected numeric type.
+var ErrNotNumber = errors.New("Value is not a number.")
+
+// Incr atomically adds delta to the int64 value of a key and returns the new value.
+// Missing or expired keys are created with delta expiring after ttl,
+// a zero ttl uses the default TTL of the cache.
+// Existing keys keep their expiration time.
+func (c *Cache) Incr(k interface{}, delta int64, ttl time.Duration) (n int64, err error) {
+	nan := false
+	_, err = c.update(k, func(old interface{}, exp time.Time, exists bool) (interface{}, time.Time, updateOp) {
+		if !exists {
+			n = delta
+			return n, c.ttlExp(ttl), opSet
+		}
+		v, ok := old.(int64)
+		if !ok {
+			nan = true
+			return old, exp, opNone
+		}
+		n = v + delta
+		return n, exp, opKeepExp
+	})
+	if nan {
+		err = ErrNotNumber
+	}
+	if err != nil {
+		n = 0
+	}
+	return
+}
+
+// Decr atomically subtracts delta from the int64 value of a key, see Incr.
+func (c *Cache) Decr(k interface{}, delta int64, ttl time.Duration) (int64, error) {
+	return c.Incr(k, -delta, ttl)
+}
+
+// IncrFloat atomically adds delta to the float64 value of a key, see Incr.
+func (c *Cache) IncrFloat(k interface{}, delta float64, ttl time.Duration) (f float64, err error) {
+	nan := false
+	_, err = c.update(k, func(old interface{}, exp time.Time, exists bool) (interface{}, time.Time, updateOp) {
+		if !exists {
+			f = delta
+			return f, c.ttlExp(ttl), opSet
+		}
+		v, ok := old.(float64)
+		if !ok {
+			nan = true
+			return old, exp, opNone
+		}
+		f = v + delta
+		return f, exp, opKeepExp
+	})
+	if nan {
+		err = ErrNotNumber
+	}
+	if err != nil {
+		f = 0
+	}
+	return
+}
+
+// DecrFloat atomically subtracts delta from the float64 value of a key, see Incr.
+func (c *Cache) DecrFloat(k interface{}, delta float64, ttl time.Duration) (float64, error) {
+	return c.IncrFloat(k, -delta, ttl)
+}
+
+func (c *Cache) ttlExp(ttl time.Duration) time.Time {
+	if ttl > 0 {
+		return c.clock.Now().Add(ttl)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,102 @@
+package cache_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+	"github.com/alxarch/go-cache/cachetest"
+)
+
+func Test_Incr(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(1000, 0))
+	c, err := cache.Build(cache.WithCapacity(10), cache.WithPolicy(cache.PolicyTTL), cache.WithClock(clock), cache.WithDefaultTTL(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ttl := c.(*cache.TTL)
+	if n, err := ttl.Incr("foo", 2, time.Minute); err != nil || n != 2 {
+		t.Errorf("Invalid Incr %d %v", n, err)
+	}
+	clock.Advance(30 * time.Second)
+	if n, err := ttl.Decr("foo", 3, time.Minute); err != nil || n != -1 {
+		t.Errorf("Invalid Decr %d %v", n, err)
+	}
+	if _, exp, _ := ttl.Get("foo"); !exp.Equal(time.Unix(1060, 0)) {
+		t.Errorf("Update changed exp %s", exp)
+	}
+	clock.Advance(time.Minute)
+	if n, err := ttl.Incr("foo", 1, 0); err != nil || n != 1 {
+		t.Errorf("Expired key not reset %d %v", n, err)
+	}
+	if _, exp, _ := ttl.Get("foo"); !exp.Equal(clock.Now().Add(time.Hour)) {
+		t.Errorf("Default TTL not applied %s", exp)
+	}
+	ttl.Set("bar", int64(1), cache.Never())
+	if n, err := ttl.Incr("bar", 1, 0); err != nil || n != 2 {
+		t.Errorf("Invalid Incr %d %v", n, err)
+	}
+	if _, exp, _ := ttl.Get("bar"); !exp.IsZero() {
+		t.Errorf("Update applied default TTL %s", exp)
+	}
+	if f, err := ttl.IncrFloat("baz", 0.5, 0); err != nil || f != 0.5 {
+		t.Errorf("Invalid IncrFloat %f %v", f, err)
+	}
+	if f, err := ttl.DecrFloat("baz", 1.5, 0); err != nil || f != -1 {
+		t.Errorf("Invalid DecrFloat %f %v", f, err)
+	}
+	if _, err := ttl.Incr("baz", 1, 0); err != cache.ErrNotNumber {
+		t.Errorf("Invalid error %v", err)
+	}
+	if _, err := ttl.IncrFloat("bar", 1, 0); err != cache.ErrNotNumber {
+		t.Errorf("Invalid error %v", err)
+	}
+}
+
+func Test_IncrConcurrent(t *testing.T) {
+	c := cache.NewLFU(10)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				c.Incr("foo", 1, time.Minute)
+				c.IncrFloat("bar", 0.5, time.Minute)
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _, _ := c.Get("foo"); v != int64(8000) {
+		t.Errorf("Invalid value %v", v)
+	}
+	if v, _, _ := c.Get("bar"); v != float64(4000) {
+		t.Errorf("Invalid value %v", v)
+	}
+}
+
+func Test_IncrLifetime(t *testing.T) {
+	for _, policy := range policies {
+		clock := cachetest.NewClock(time.Unix(1000, 0))
+		c, err := cache.Build(cache.WithCapacity(10), cache.WithPolicy(policy), cache.WithClock(clock), cache.WithSlidingTTL(time.Second, 2*time.Second))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cc := c.(interface {
+			cache.Interface
+			Incr(k interface{}, delta int64, ttl time.Duration) (int64, error)
+		})
+		cc.Incr("foo", 1, 0)
+		// Increments do not restart the max lifetime of the counter
+		for i := 0; i < 3; i++ {
+			clock.Advance(600 * time.Millisecond)
+			cc.Incr("foo", 1, 0)
+			cc.Get("foo")
+		}
+		clock.Advance(600 * time.Millisecond)
+		if v, _, err := cc.Get("foo"); err != cache.ErrExpired {
+			t.Errorf("%s: Counter outlived max lifetime %v %v", policy, v, err)
+		}
+	}
+}
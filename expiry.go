@@ -0,0 +1,67 @@
+package cache
+
+import "container/heap"
+
+// expiryHeap is a min-heap of entries that expire ordered by expiration time.
+// Entries keep their position in index, entries not in the heap have a negative index.
+type expiryHeap []*entry
+
+func (h expiryHeap) Len() int {
+	return len(h)
+}
+
+func (h expiryHeap) Less(i, j int) bool {
+	return h[i].exp.Before(h[j].exp)
+}
+
+func (h expiryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *expiryHeap) Push(x interface{}) {
+	e := x.(*entry)
+	e.index = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *expiryHeap) Pop() interface{} {
+	old := *h
+	n := len(old) - 1
+	e := old[n]
+	old[n] = nil
+	e.index = -1
+	*h = old[:n]
+	return e
+}
+
+// schedule updates the position of an entry after its expiration time changed.
+// The caller must hold the lock.
+func (c *Cache) schedule(e *entry) {
+	switch {
+	case e.index >= 0 && e.exp.IsZero():
+		heap.Remove(&c.expiry, e.index)
+	case e.index >= 0:
+		heap.Fix(&c.expiry, e.index)
+	case !e.exp.IsZero():
+		heap.Push(&c.expiry, e)
+	}
+}
+
+// unschedule removes an entry from the heap, the caller must hold the lock.
+func (c *Cache) unschedule(e *entry) {
+	if e.index >= 0 {
+		heap.Remove(&c.expiry, e.index)
+	}
+}
+
+// soonest returns the key that expires first.
+func (c *Cache) soonest() (k interface{}, ok bool) {
+	c.mu.RLock()
+	if len(c.expiry) > 0 {
+		k, ok = c.expiry[0].key, true
+	}
+	c.mu.RUnlock()
+	return
+}
@@ -0,0 +1,89 @@
+package cache_test
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+func Test_TrimExpiry(t *testing.T) {
+	for _, policy := range policies {
+		c, err := cache.Build(cache.WithCapacity(100), cache.WithPolicy(policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+		now := time.Now()
+		for i := 0; i < 20; i++ {
+			c.Set(i, i, now.Add(time.Duration(i)*time.Minute))
+		}
+		c.Set("never", 0, time.Time{})
+		// Overwrites move keys in the index
+		c.Set(0, 0, now.Add(time.Hour))
+		c.Set(19, 19, now.Add(-time.Minute))
+		c.Evict(1)
+		expired := c.(interface {
+			Trim(time.Time) []interface{}
+		}).Trim(now.Add(5*time.Minute + time.Second))
+		keys := make([]string, len(expired))
+		for i, k := range expired {
+			keys[i] = fmt.Sprint(k)
+		}
+		sort.Strings(keys)
+		if fmt.Sprint(keys) != "[19 2 3 4 5]" {
+			t.Errorf("%s: Invalid trimmed keys %v", policy, keys)
+		}
+		if n := c.Evict(); n != 15 {
+			t.Errorf("%s: Invalid size %d", policy, n)
+		}
+		if _, _, err := c.Get(0); err != nil {
+			t.Errorf("%s: Invalid error %v", policy, err)
+		}
+	}
+}
+
+func Test_TrimTouch(t *testing.T) {
+	c := cache.New(0)
+	now := time.Now()
+	c.Set("foo", 1, now.Add(time.Minute))
+	c.Set("bar", 2, now.Add(time.Minute))
+	c.Touch("foo", now.Add(time.Hour))
+	c.Touch("bar", cache.Never())
+	if expired := c.Trim(now.Add(2 * time.Minute)); len(expired) != 0 {
+		t.Errorf("Invalid trimmed keys %v", expired)
+	}
+	if expired := c.Trim(now.Add(2 * time.Hour)); len(expired) != 1 || expired[0] != "foo" {
+		t.Errorf("Invalid trimmed keys %v", expired)
+	}
+}
+
+func Test_TTLEvictSoonest(t *testing.T) {
+	c := cache.NewTTL(3)
+	now := time.Now()
+	c.Set("foo", 1, now.Add(3*time.Minute))
+	c.Set("bar", 2, now.Add(time.Minute))
+	c.Set("baz", 3, time.Time{})
+	c.Set("qux", 4, now.Add(2*time.Minute))
+	if _, _, err := c.Get("bar"); err != cache.ErrKeyNotFound {
+		t.Errorf("Invalid error %v", err)
+	}
+	c.Set("bar", 2, now.Add(time.Hour))
+	if _, _, err := c.Get("qux"); err != cache.ErrKeyNotFound {
+		t.Errorf("Invalid error %v", err)
+	}
+}
+
+func BenchmarkTrim(b *testing.B) {
+	c := cache.New(0)
+	now := time.Now()
+	for i := 0; i < 100000; i++ {
+		c.Set(i, i, now.Add(time.Hour))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Set("due", i, now)
+		c.Trim(now.Add(time.Second))
+	}
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"container/list"
+	"iter"
 	"sync"
 	"time"
 )
@@ -18,12 +19,17 @@ func NewFIFO(size int) *FIFO {
 	if size <= 0 {
 		return nil
 	}
-	return &FIFO{
-		Cache: New(size),
-		index: make(map[interface{}]*list.Element, size),
+	return newFIFO(New(size))
+}
+
+func newFIFO(c *Cache) *FIFO {
+	fifo := &FIFO{
+		Cache: c,
+		index: make(map[interface{}]*list.Element),
 		list:  list.New(),
 	}
-
+	c.policy = fifo
+	return fifo
 }
 
 func (c *FIFO) Get(k interface{}) (v interface{}, exp time.Time, err error) {
@@ -33,43 +39,89 @@ func (c *FIFO) Get(k interface{}) (v interface{}, exp time.Time, err error) {
 // Set assigns a value to a key and sets the expiration time.
 // If the size limit is reached the oldest item stored is evicted to insert the new one
 func (c *FIFO) Set(k, v interface{}, exp time.Time) (err error) {
+	exp = c.Cache.expires(exp)
 	c.mu.Lock()
+	err = c.setLocked(k, v, exp)
+	c.mu.Unlock()
+	return
+}
+
+func (c *FIFO) setLocked(k, v interface{}, exp time.Time) (err error) {
+	next := c.list.Back()
+	admitted := false
 	for {
-		if err = c.Cache.Set(k, v, exp); err != ErrMaxSize {
+		if err = c.Cache.set(k, v, exp); err != ErrMaxSize {
 			break
 		}
-		if el := c.list.Back(); el != nil {
-			key := c.list.Remove(el)
-			delete(c.index, key)
-			c.Cache.Evict(key)
-		} else {
+		el := next
+		for el != nil && c.Cache.protected(el.Value) {
+			el = el.Prev()
+		}
+		if el == nil {
 			break
 		}
+		if !admitted {
+			if err = c.Cache.admit(k, v, el.Value); err != nil {
+				return
+			}
+			admitted = true
+		}
+		next = el.Prev()
+		key := c.list.Remove(el)
+		delete(c.index, key)
+		c.Cache.discard(key)
 	}
 	if err == nil {
-		if el, ok := c.index[k]; !ok {
+		if _, ok := c.index[k]; !ok {
 			c.index[k] = c.list.PushFront(k)
-		} else {
-			c.list.PushFront(el)
 		}
-
 	}
-	c.mu.Unlock()
 	return
 }
 
 func (c *FIFO) Evict(keys ...interface{}) int {
 	c.mu.Lock()
+	n := c.evictLocked(keys)
+	c.mu.Unlock()
+	return n
+}
+
+func (c *FIFO) evictLocked(keys []interface{}) int {
 	for _, k := range keys {
 		if el := c.index[k]; el != nil {
 			c.list.Remove(el)
 			delete(c.index, k)
 		}
 	}
-	c.mu.Unlock()
-
 	return c.Cache.Evict(keys...)
+}
+
+func (c *FIFO) lockPolicy()   { c.mu.Lock() }
+func (c *FIFO) unlockPolicy() { c.mu.Unlock() }
+
+// Ordered returns an iterator over a snapshot of the items in insertion order.
+func (c *FIFO) Ordered() iter.Seq2[interface{}, Item] {
+	return c.Cache.ordered()
+}
+
+func (c *FIFO) orderLocked() []interface{} {
+	keys := make([]interface{}, 0, c.list.Len())
+	for el := c.list.Back(); el != nil; el = el.Prev() {
+		keys = append(keys, el.Value)
+	}
+	return keys
+}
+
+func (c *FIFO) forgetLocked(k interface{}) {
+	if el := c.index[k]; el != nil {
+		c.list.Remove(el)
+		delete(c.index, k)
+	}
+}
 
+func (c *FIFO) resetLocked() {
+	c.index = make(map[interface{}]*list.Element)
+	c.list.Init()
 }
 
 func (c *FIFO) Trim(now time.Time) []interface{} {
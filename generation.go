@@ -0,0 +1,92 @@
+package cache
+
+import "time"
+
+// GenerationKey is the key under which a Generation stores items in its cache.
+// Eviction hooks and iterators see keys set through a Generation as GenerationKey.
+type GenerationKey struct {
+	Namespace interface{}
+	Gen       uint64
+	Key       interface{}
+}
+
+// Generation is a view of the items of a namespace in a cache that can all be invalidated at once.
+// Items are stored with the current generation of the namespace and calling Bump makes them unreachable.
+// Items of older generations are reclaimed by Trim or the eviction policy of the cache.
+type Generation struct {
+	cache     Interface
+	base      *Cache
+	namespace interface{}
+}
+
+var _ Interface = (*Generation)(nil)
+
+// Generation returns a view of the items of a namespace.
+// Items are set through the eviction policy of the cache if it has one.
+func (c *Cache) Generation(namespace interface{}) *Generation {
+	var front Interface = c
+	if p, ok := c.policy.(Interface); ok {
+		front = p
+	}
+	return &Generation{
+		cache:     front,
+		base:      c,
+		namespace: namespace,
+	}
+}
+
+// Gen returns the current generation of the namespace.
+func (g *Generation) Gen() (gen uint64) {
+	g.base.mu.RLock()
+	gen = g.base.gens[g.namespace]
+	g.base.mu.RUnlock()
+	return
+}
+
+// Bump invalidates all items of the namespace and returns the new generation.
+func (g *Generation) Bump() (gen uint64) {
+	c := g.base
+	c.mu.Lock()
+	if c.gens == nil {
+		c.gens = make(map[interface{}]uint64)
+	}
+	gen = c.gens[g.namespace] + 1
+	c.gens[g.namespace] = gen
+	c.stale = true
+	c.mu.Unlock()
+	return
+}
+
+func (g *Generation) key(k interface{}) GenerationKey {
+	return GenerationKey{g.namespace, g.Gen(), k}
+}
+
+// Get returns a value of the current generation.
+func (g *Generation) Get(k interface{}) (interface{}, time.Time, error) {
+	return g.cache.Get(g.key(k))
+}
+
+// Set assigns a value to a key in the current generation.
+func (g *Generation) Set(k, v interface{}, exp time.Time) error {
+	return g.cache.Set(g.key(k), v, exp)
+}
+
+// Evict removes keys of the current generation and returns the size of the cache.
+func (g *Generation) Evict(keys ...interface{}) int {
+	gk := make([]interface{}, len(keys))
+	for i, k := range keys {
+		gk[i] = g.key(k)
+	}
+	return g.cache.Evict(gk...)
+}
+
+// Metrics returns the metrics of the cache.
+func (g *Generation) Metrics() Metrics {
+	return g.cache.Metrics()
+}
+
+// isStale reports whether a key belongs to an older generation, the caller must hold the lock.
+func (c *Cache) isStale(k interface{}) bool {
+	gk, ok := k.(GenerationKey)
+	return ok && gk.Gen < c.gens[gk.Namespace]
+}
@@ -0,0 +1,48 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+func Test_Generation(t *testing.T) {
+	c := cache.NewLRU(10)
+	users := c.Generation("users")
+	posts := c.Generation("posts")
+	users.Set("foo", 1, time.Time{})
+	users.Set("bar", 2, time.Time{})
+	posts.Set("foo", 3, time.Time{})
+	if v, _, err := users.Get("foo"); err != nil || v != 1 {
+		t.Errorf("Invalid value %v %v", v, err)
+	}
+	if gen := users.Bump(); gen != 1 || users.Gen() != 1 || posts.Gen() != 0 {
+		t.Errorf("Invalid generation %d", gen)
+	}
+	if _, _, err := users.Get("foo"); err != cache.ErrKeyNotFound {
+		t.Errorf("Invalid error %v", err)
+	}
+	if v, _, err := posts.Get("foo"); err != nil || v != 3 {
+		t.Errorf("Invalid value %v %v", v, err)
+	}
+	users.Set("foo", 4, time.Time{})
+	if v, _, err := users.Get("foo"); err != nil || v != 4 {
+		t.Errorf("Invalid value %v %v", v, err)
+	}
+	expired := c.Trim(time.Now())
+	if len(expired) != 2 {
+		t.Errorf("Invalid trim %v", expired)
+	}
+	for _, k := range expired {
+		if gk := k.(cache.GenerationKey); gk.Namespace != "users" || gk.Gen != 0 {
+			t.Errorf("Invalid key %v", gk)
+		}
+	}
+	if n := c.Evict(); n != 2 {
+		t.Errorf("Invalid size %d", n)
+	}
+	if n := users.Evict("foo"); n != 1 {
+		t.Errorf("Invalid size %d", n)
+	}
+}
@@ -0,0 +1,190 @@
+// Package invalidation propagates cache invalidations between replicas.
+package invalidation
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+// Kind is the kind of an invalidation event.
+type Kind uint8
+
+const (
+	// KindEvict events drop keys from all peers.
+	KindEvict Kind = iota + 1
+	// KindSet events announce a new version of keys, peers with older versions drop them.
+	KindSet
+)
+
+// Event is broadcast to peers on local writes.
+type Event struct {
+	Origin  string
+	Seq     uint64
+	Kind    Kind
+	Version uint64
+	Keys    []interface{}
+}
+
+// Transport delivers events between peers.
+// Transports may deliver events more than once and may echo events back to their origin.
+type Transport interface {
+	// Publish sends an event to all peers.
+	Publish(e Event) error
+	// Subscribe registers a handler for received events.
+	Subscribe(h func(e Event))
+}
+
+// maxVersions bounds the number of tracked key versions.
+// When exceeded tracking restarts and any Set event from peers evicts the local copy.
+const maxVersions = 1 << 16
+
+// Bus wraps a local cache broadcasting Evict and Set to peers and applying their events locally.
+type Bus struct {
+	cache.Interface
+	id        string
+	transport Transport
+	seq       uint64
+
+	// Protects clock, versions and seen
+	mu       sync.Mutex
+	clock    uint64
+	versions map[interface{}]uint64
+	seen     map[string]*window
+}
+
+// New returns a Bus for a local cache and subscribes it to the transport.
+func New(c cache.Interface, t Transport) *Bus {
+	id := make([]byte, 8)
+	rand.Read(id)
+	b := &Bus{
+		Interface: c,
+		id:        hex.EncodeToString(id),
+		transport: t,
+		versions:  make(map[interface{}]uint64),
+		seen:      make(map[string]*window),
+	}
+	t.Subscribe(b.apply)
+	return b
+}
+
+// ID returns the origin id of events published by the bus.
+func (b *Bus) ID() string {
+	return b.id
+}
+
+func (b *Bus) publish(kind Kind, version uint64, keys []interface{}) error {
+	return b.transport.Publish(Event{
+		Origin:  b.id,
+		Seq:     atomic.AddUint64(&b.seq, 1),
+		Kind:    kind,
+		Version: version,
+		Keys:    keys,
+	})
+}
+
+// Set assigns a value to a key locally and notifies peers to drop older versions.
+// Only local errors are returned, the value is set even if the transport fails to publish the event as with Evict.
+func (b *Bus) Set(k, v interface{}, exp time.Time) error {
+	if err := b.Interface.Set(k, v, exp); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.clock++
+	version := b.clock
+	b.track(k, version)
+	b.mu.Unlock()
+	b.publish(KindSet, version, []interface{}{k})
+	return nil
+}
+
+// Evict drops keys locally and from all peers.
+func (b *Bus) Evict(keys ...interface{}) int {
+	n := b.Interface.Evict(keys...)
+	if len(keys) > 0 {
+		b.mu.Lock()
+		for _, k := range keys {
+			delete(b.versions, k)
+		}
+		b.mu.Unlock()
+		b.publish(KindEvict, 0, keys)
+	}
+	return n
+}
+
+func (b *Bus) track(k interface{}, version uint64) {
+	if _, ok := b.versions[k]; !ok && len(b.versions) >= maxVersions {
+		b.versions = make(map[interface{}]uint64)
+	}
+	b.versions[k] = version
+}
+
+func (b *Bus) apply(e Event) {
+	if e.Origin == b.id {
+		return
+	}
+	b.mu.Lock()
+	w := b.seen[e.Origin]
+	if w == nil {
+		w = new(window)
+		b.seen[e.Origin] = w
+	}
+	if !w.accept(e.Seq) {
+		b.mu.Unlock()
+		return
+	}
+	var stale []interface{}
+	switch e.Kind {
+	case KindEvict:
+		stale = e.Keys
+		for _, k := range e.Keys {
+			delete(b.versions, k)
+		}
+	case KindSet:
+		if e.Version > b.clock {
+			b.clock = e.Version
+		}
+		for _, k := range e.Keys {
+			if e.Version >= b.versions[k] {
+				b.track(k, e.Version)
+				stale = append(stale, k)
+			}
+		}
+	}
+	b.mu.Unlock()
+	if len(stale) > 0 {
+		b.Interface.Evict(stale...)
+	}
+}
+
+// window tracks the last 64 sequence numbers seen from an origin.
+type window struct {
+	max  uint64
+	bits uint64
+}
+
+func (w *window) accept(seq uint64) bool {
+	switch {
+	case seq > w.max:
+		if shift := seq - w.max; shift < 64 {
+			w.bits = w.bits<<shift | 1
+		} else {
+			w.bits = 1
+		}
+		w.max = seq
+		return true
+	case w.max-seq >= 64:
+		return false
+	default:
+		mask := uint64(1) << (w.max - seq)
+		if w.bits&mask != 0 {
+			return false
+		}
+		w.bits |= mask
+		return true
+	}
+}
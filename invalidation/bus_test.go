@@ -0,0 +1,235 @@
+package invalidation_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+	"github.com/alxarch/go-cache/invalidation"
+)
+
+func Test_Bus(t *testing.T) {
+	hub := invalidation.NewHub()
+	a := invalidation.New(cache.New(0), hub.Transport())
+	b := invalidation.New(cache.New(0), hub.Transport())
+	b.Interface.Set("foo", "stale", cache.Never())
+	if err := a.Set("foo", "bar", cache.Never()); err != nil {
+		t.Errorf("Unexpected error %s", err)
+	}
+	if v, _, err := a.Get("foo"); err != nil || v != "bar" {
+		t.Errorf("Echo evicted local value %v %v", v, err)
+	}
+	if _, _, err := b.Get("foo"); err != cache.ErrKeyNotFound {
+		t.Errorf("Stale value not evicted %v", err)
+	}
+	b.Set("foo", "baz", cache.Never())
+	if _, _, err := a.Get("foo"); err != cache.ErrKeyNotFound {
+		t.Errorf("Stale value not evicted %v", err)
+	}
+	a.Interface.Set("foo", "baz", cache.Never())
+	// Replayed events are ignored
+	b.Interface.Set("bar", "baz", cache.Never())
+	e := invalidation.Event{Origin: a.ID(), Seq: 1, Kind: invalidation.KindEvict, Keys: []interface{}{"bar"}}
+	hub.Transport().Publish(e)
+	if _, _, err := b.Get("bar"); err != nil {
+		t.Errorf("Duplicate event was applied %v", err)
+	}
+	// Older versions are ignored
+	e = invalidation.Event{Origin: "other", Seq: 1, Kind: invalidation.KindSet, Version: 1, Keys: []interface{}{"foo"}}
+	hub.Transport().Publish(e)
+	if _, _, err := a.Get("foo"); err != nil {
+		t.Errorf("Older version was applied %v", err)
+	}
+	if n := a.Evict("foo"); n != 0 {
+		t.Errorf("Invalid size %d", n)
+	}
+	b.Interface.Set("foo", "baz", cache.Never())
+	a.Evict("foo", "bar")
+	if n := b.Evict(); n != 0 {
+		t.Errorf("Keys not evicted from peer %d", n)
+	}
+}
+
+func Test_TCP(t *testing.T) {
+	const numPeers = 3
+	transports := make([]*invalidation.TCP, numPeers)
+	buses := make([]*invalidation.Bus, numPeers)
+	addrs := make([]string, numPeers)
+	received := make(chan invalidation.Event, numPeers*numPeers)
+	for i := range transports {
+		tr, err := invalidation.ListenTCP("127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer tr.Close()
+		transports[i] = tr
+		addrs[i] = tr.Addr().String()
+		buses[i] = invalidation.New(cache.New(0), tr)
+		// Subscribe after the bus so events are applied before they are received
+		tr.Subscribe(func(e invalidation.Event) {
+			received <- e
+		})
+	}
+	for i, tr := range transports {
+		tr.SetPeers(append(addrs[:i:i], addrs[i+1:]...)...)
+		buses[i].Interface.Set("foo", i, cache.Never())
+	}
+	buses[0].Evict("foo")
+	for i := 0; i < numPeers-1; i++ {
+		select {
+		case e := <-received:
+			if e.Kind != invalidation.KindEvict || e.Origin != buses[0].ID() || len(e.Keys) != 1 || e.Keys[0] != "foo" {
+				t.Errorf("Invalid event %v", e)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timeout")
+		}
+	}
+	for i, b := range buses {
+		if _, _, err := b.Get("foo"); err != cache.ErrKeyNotFound {
+			t.Errorf("Key not evicted from peer %d", i)
+		}
+	}
+}
+
+func Test_TCPConcurrent(t *testing.T) {
+	const publishers, events = 4, 3
+	a, err := invalidation.ListenTCP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+	b, err := invalidation.ListenTCP("127.0.0.1:0", a.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+	a.SetPeers(b.Addr().String())
+	received := make(chan invalidation.Event, 2*publishers*events)
+	for _, tr := range []*invalidation.TCP{a, b} {
+		tr.Subscribe(func(e invalidation.Event) {
+			received <- e
+		})
+	}
+	keys := make([]interface{}, 20000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%032d", i)
+	}
+	errs := make(chan error, 2*publishers)
+	// Both sides publish large events to each other at the same time
+	for _, tr := range []*invalidation.TCP{a, b} {
+		for i := 0; i < publishers; i++ {
+			go func(tr *invalidation.TCP) {
+				var err error
+				for j := 0; j < events && err == nil; j++ {
+					err = tr.Publish(invalidation.Event{Kind: invalidation.KindEvict, Keys: keys})
+				}
+				errs <- err
+			}(tr)
+		}
+	}
+	timeout := time.After(30 * time.Second)
+	for i := 0; i < 2*publishers; i++ {
+		select {
+		case err := <-errs:
+			if err != nil {
+				t.Errorf("Unexpected error %s", err)
+			}
+		case <-timeout:
+			t.Fatal("Timeout")
+		}
+	}
+	for i := 0; i < 2*publishers*events; i++ {
+		select {
+		case e := <-received:
+			if len(e.Keys) != len(keys) {
+				t.Errorf("Invalid event keys %d", len(e.Keys))
+			}
+		case <-timeout:
+			t.Fatal("Timeout")
+		}
+	}
+}
+
+type failing struct{}
+
+func (failing) Publish(e invalidation.Event) error {
+	return errors.New("Unavailable")
+}
+
+func (failing) Subscribe(h func(e invalidation.Event)) {}
+
+func Test_BusPublishError(t *testing.T) {
+	b := invalidation.New(cache.New(0), failing{})
+	if err := b.Set("foo", "bar", time.Time{}); err != nil {
+		t.Errorf("Unexpected error %s", err)
+	}
+	if v, _, err := b.Get("foo"); err != nil || v != "bar" {
+		t.Errorf("Invalid value %v %v", v, err)
+	}
+}
+
+func Test_TCPSlowPeer(t *testing.T) {
+	// The peer accepts connections but never reads from them
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	var (
+		mu    sync.Mutex
+		conns []net.Conn
+	)
+	defer func() {
+		mu.Lock()
+		for _, conn := range conns {
+			conn.Close()
+		}
+		mu.Unlock()
+	}()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+		}
+	}()
+	// The other peer is unreachable
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	tr, err := invalidation.ListenTCP("127.0.0.1:0", l.Addr().String(), closed.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.Close()
+	keys := make([]interface{}, 1000)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%032d", i)
+	}
+	start := time.Now()
+	full := false
+	for i := 0; i < 2*invalidation.QueueSize; i++ {
+		if err := tr.Publish(invalidation.Event{Kind: invalidation.KindEvict, Keys: keys}); err == invalidation.ErrQueueFull {
+			full = true
+		} else if err != nil {
+			t.Fatalf("Unexpected error %s", err)
+		}
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Publish waited for peers %s", d)
+	}
+	if !full {
+		t.Error("Queue not full")
+	}
+}
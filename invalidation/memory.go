@@ -0,0 +1,40 @@
+package invalidation
+
+import "sync"
+
+// Hub connects in-memory transports within a process.
+type Hub struct {
+	mu       sync.RWMutex
+	handlers []func(Event)
+}
+
+// NewHub returns an empty Hub.
+func NewHub() *Hub {
+	return &Hub{}
+}
+
+// Transport returns a new transport attached to the hub.
+func (h *Hub) Transport() Transport {
+	return &memory{hub: h}
+}
+
+type memory struct {
+	hub *Hub
+}
+
+// Publish delivers an event synchronously to all transports of the hub including the sender.
+func (m *memory) Publish(e Event) error {
+	m.hub.mu.RLock()
+	handlers := m.hub.handlers
+	m.hub.mu.RUnlock()
+	for _, h := range handlers {
+		h(e)
+	}
+	return nil
+}
+
+func (m *memory) Subscribe(h func(Event)) {
+	m.hub.mu.Lock()
+	m.hub.handlers = append(m.hub.handlers, h)
+	m.hub.mu.Unlock()
+}
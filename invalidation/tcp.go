@@ -0,0 +1,252 @@
+package invalidation
+
+import (
+	"encoding/gob"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DialTimeout is the timeout for connecting to TCP peers.
+const DialTimeout = time.Second
+
+// WriteTimeout is the timeout for sending an event to a TCP peer.
+const WriteTimeout = 5 * time.Second
+
+// QueueSize is the number of events queued for each TCP peer.
+const QueueSize = 1024
+
+// MaxBackoff is the maximum delay before retrying an event after a TCP peer failed.
+const MaxBackoff = 10 * time.Second
+
+// minBackoff is the delay before the first retry.
+const minBackoff = 50 * time.Millisecond
+
+// ErrQueueFull is returned by Publish if events for a peer were dropped because its queue is full.
+var ErrQueueFull = errors.New("Queue full.")
+
+// TCP is a Transport that fans out events to peers over TCP connections.
+// Events are encoded with encoding/gob so custom key types must be registered with gob.Register.
+type TCP struct {
+	l net.Listener
+
+	mu     sync.Mutex
+	closed bool
+	peers  map[string]*tcpPeer
+	conns  map[net.Conn]struct{}
+	// handlers is copied on write so that receivers never wait for the lock
+	handlers atomic.Pointer[[]func(Event)]
+}
+
+// tcpPeer sends events to a peer from a background goroutine so that publishers never wait for the network.
+// Events are queued up to QueueSize and retried with exponential backoff until the peer is closed.
+type tcpPeer struct {
+	addr  string
+	queue chan Event
+	done  chan struct{}
+	// Protects closed and conn so that close interrupts a stalled write
+	mu     sync.Mutex
+	closed bool
+	conn   net.Conn
+	enc    *gob.Encoder
+}
+
+// ListenTCP returns a TCP transport accepting events on addr and publishing to peers.
+func ListenTCP(addr string, peers ...string) (*TCP, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	t := &TCP{
+		l:     l,
+		conns: make(map[net.Conn]struct{}),
+	}
+	t.SetPeers(peers...)
+	go t.accept()
+	return t, nil
+}
+
+// Addr returns the address the transport listens on.
+func (t *TCP) Addr() net.Addr {
+	return t.l.Addr()
+}
+
+// SetPeers replaces the addresses events are published to.
+func (t *TCP) SetPeers(addrs ...string) {
+	peers := make(map[string]*tcpPeer, len(addrs))
+	var removed []*tcpPeer
+	t.mu.Lock()
+	for _, addr := range addrs {
+		if p := t.peers[addr]; p != nil {
+			peers[addr] = p
+		} else {
+			peers[addr] = newTCPPeer(addr)
+		}
+	}
+	for addr, p := range t.peers {
+		if _, ok := peers[addr]; !ok {
+			removed = append(removed, p)
+		}
+	}
+	t.peers = peers
+	t.mu.Unlock()
+	for _, p := range removed {
+		p.close()
+	}
+}
+
+// Publish queues an event for all peers without waiting for the network.
+// It returns ErrQueueFull if the queue of a peer that is slow or unreachable is full and the event was dropped for it.
+// Connections to peers are established lazily and re-established after errors.
+func (t *TCP) Publish(e Event) (err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, p := range t.peers {
+		select {
+		case p.queue <- e:
+		default:
+			err = ErrQueueFull
+		}
+	}
+	return
+}
+
+func newTCPPeer(addr string) *tcpPeer {
+	p := &tcpPeer{
+		addr:  addr,
+		queue: make(chan Event, QueueSize),
+		done:  make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// run sends queued events until the peer is closed.
+func (p *tcpPeer) run() {
+	for {
+		select {
+		case <-p.done:
+			return
+		case e := <-p.queue:
+			for backoff := minBackoff; p.send(&e) != nil; backoff = min(2*backoff, MaxBackoff) {
+				select {
+				case <-p.done:
+					return
+				case <-time.After(backoff):
+				}
+			}
+		}
+	}
+}
+
+// send encodes an event to the peer connecting if needed.
+// Only the run goroutine sends so the connection is only written to without the lock.
+func (p *tcpPeer) send(e *Event) error {
+	if p.enc == nil {
+		conn, err := net.DialTimeout("tcp", p.addr, DialTimeout)
+		if err != nil {
+			return err
+		}
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			conn.Close()
+			return nil
+		}
+		p.conn, p.enc = conn, gob.NewEncoder(conn)
+		p.mu.Unlock()
+	}
+	p.conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	if err := p.enc.Encode(e); err != nil {
+		// A partially written event leaves the stream unusable
+		p.mu.Lock()
+		p.conn.Close()
+		p.conn, p.enc = nil, nil
+		p.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// close closes the connection to the peer and stops its sender dropping queued events.
+func (p *tcpPeer) close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.done)
+		if p.conn != nil {
+			p.conn.Close()
+		}
+	}
+	p.mu.Unlock()
+}
+
+// Subscribe implements Transport.
+func (t *TCP) Subscribe(h func(Event)) {
+	t.mu.Lock()
+	var handlers []func(Event)
+	if old := t.handlers.Load(); old != nil {
+		handlers = append(handlers, *old...)
+	}
+	handlers = append(handlers, h)
+	t.handlers.Store(&handlers)
+	t.mu.Unlock()
+}
+
+// Close stops listening and closes all connections.
+func (t *TCP) Close() error {
+	t.mu.Lock()
+	t.closed = true
+	peers := t.peers
+	t.peers = nil
+	for conn := range t.conns {
+		conn.Close()
+	}
+	err := t.l.Close()
+	t.mu.Unlock()
+	for _, p := range peers {
+		p.close()
+	}
+	return err
+}
+
+func (t *TCP) accept() {
+	for {
+		conn, err := t.l.Accept()
+		if err != nil {
+			return
+		}
+		t.mu.Lock()
+		if t.closed {
+			t.mu.Unlock()
+			conn.Close()
+			return
+		}
+		t.conns[conn] = struct{}{}
+		t.mu.Unlock()
+		go t.receive(conn)
+	}
+}
+
+func (t *TCP) receive(conn net.Conn) {
+	defer func() {
+		t.mu.Lock()
+		delete(t.conns, conn)
+		t.mu.Unlock()
+		conn.Close()
+	}()
+	dec := gob.NewDecoder(conn)
+	for {
+		e := Event{}
+		if err := dec.Decode(&e); err != nil {
+			return
+		}
+		if handlers := t.handlers.Load(); handlers != nil {
+			for _, h := range *handlers {
+				h(e)
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"iter"
+	"time"
+)
+
+// Item is a value stored in cache with its expiration time.
+// Items without expiration have a zero Exp.
+type Item struct {
+	Value interface{}
+	Exp   time.Time
+}
+
+// ranger is implemented by caches that support iteration.
+type ranger interface {
+	All() iter.Seq2[interface{}, Item]
+}
+
+// Range calls fn for each item in cache that has not expired until fn returns false.
+// fn is called on a snapshot of the cache and may call back into it.
+func (c *Cache) Range(fn func(k, v interface{}, exp time.Time) bool) {
+	for k, item := range c.All() {
+		if !fn(k, item.Value, item.Exp) {
+			return
+		}
+	}
+}
+
+// Keys returns the keys of items in cache that have not expired.
+func (c *Cache) Keys() []interface{} {
+	now := c.clock.Now()
+	c.mu.RLock()
+	keys := make([]interface{}, 0, len(c.values))
+	for k, e := range c.values {
+		if e.exp.IsZero() || e.exp.After(now) {
+			keys = append(keys, k)
+		}
+	}
+	c.mu.RUnlock()
+	return keys
+}
+
+// All returns an iterator over a snapshot of the items in cache that have not expired.
+// Items are iterated in no particular order.
+func (c *Cache) All() iter.Seq2[interface{}, Item] {
+	return func(yield func(interface{}, Item) bool) {
+		now := c.clock.Now()
+		c.mu.RLock()
+		keys := make([]interface{}, 0, len(c.values))
+		items := make([]Item, 0, len(c.values))
+		for k, e := range c.values {
+			if e.exp.IsZero() || e.exp.After(now) {
+				keys = append(keys, k)
+				items = append(items, Item{e.value, e.exp})
+			}
+		}
+		c.mu.RUnlock()
+		for i, k := range keys {
+			if !yield(k, items[i]) {
+				return
+			}
+		}
+	}
+}
+
+// ordered returns an iterator over a snapshot of the items in the eviction order of the cache policy.
+func (c *Cache) ordered() iter.Seq2[interface{}, Item] {
+	return func(yield func(interface{}, Item) bool) {
+		c.policy.lockPolicy()
+		keys := c.policy.orderLocked()
+		c.policy.unlockPolicy()
+		items := make([]Item, 0, len(keys))
+		now := c.clock.Now()
+		c.mu.RLock()
+		n := 0
+		for _, k := range keys {
+			if e := c.values[k]; e != nil && (e.exp.IsZero() || e.exp.After(now)) {
+				keys[n] = k
+				items = append(items, Item{e.value, e.exp})
+				n++
+			}
+		}
+		c.mu.RUnlock()
+		for i, k := range keys[:n] {
+			if !yield(k, items[i]) {
+				return
+			}
+		}
+	}
+}
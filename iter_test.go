@@ -0,0 +1,106 @@
+package cache_test
+
+import (
+	"iter"
+	"sort"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+	"github.com/alxarch/go-cache/cachetest"
+)
+
+func keys(seq iter.Seq2[interface{}, cache.Item]) (keys []interface{}) {
+	for k := range seq {
+		keys = append(keys, k)
+	}
+	return
+}
+
+func Test_Range(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(1000, 0))
+	c, err := cache.Build(cache.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc := c.(*cache.Cache)
+	cc.Set("foo", 1, time.Time{})
+	cc.Set("bar", 2, cc.Exp(time.Minute))
+	cc.Set("baz", 3, cc.Exp(time.Second))
+	clock.Advance(time.Second)
+	ks := make([]string, 0)
+	for _, k := range cc.Keys() {
+		ks = append(ks, k.(string))
+	}
+	sort.Strings(ks)
+	if len(ks) != 2 || ks[0] != "bar" || ks[1] != "foo" {
+		t.Errorf("Invalid keys %v", ks)
+	}
+	for k, item := range cc.All() {
+		switch k {
+		case "foo":
+			if item.Value != 1 || !item.Exp.IsZero() {
+				t.Errorf("Invalid item %v", item)
+			}
+		case "bar":
+			if item.Value != 2 || !item.Exp.Equal(time.Unix(1060, 0)) {
+				t.Errorf("Invalid item %v", item)
+			}
+		default:
+			t.Errorf("Invalid key %v", k)
+		}
+		// Iteration works on a snapshot
+		cc.Evict(k)
+	}
+	if n := cc.Evict(); n != 1 {
+		t.Errorf("Invalid size %d", n)
+	}
+	cc.Set("foo", 1, time.Time{})
+	cc.Set("bar", 2, time.Time{})
+	n := 0
+	cc.Range(func(k, v interface{}, exp time.Time) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Errorf("Range did not stop %d", n)
+	}
+}
+
+func Test_Ordered(t *testing.T) {
+	fifo := cache.NewFIFO(10)
+	fifo.Set("foo", 1, time.Time{})
+	fifo.Set("bar", 2, time.Time{})
+	fifo.Set("baz", 3, time.Time{})
+	if ks := keys(fifo.Ordered()); len(ks) != 3 || ks[0] != "foo" || ks[1] != "bar" || ks[2] != "baz" {
+		t.Errorf("Invalid FIFO order %v", ks)
+	}
+
+	lru := cache.NewLRU(10)
+	lru.Set("foo", 1, time.Time{})
+	lru.Set("bar", 2, time.Time{})
+	lru.Get("bar")
+	lru.Get("foo")
+	if ks := keys(lru.Ordered()); len(ks) != 2 || ks[0] != "bar" || ks[1] != "foo" {
+		t.Errorf("Invalid LRU order %v", ks)
+	}
+
+	lfu := cache.NewLFU(10)
+	lfu.Set("foo", 1, time.Time{})
+	lfu.Set("bar", 2, time.Time{})
+	lfu.Get("foo")
+	lfu.Get("foo")
+	lfu.Get("bar")
+	if ks := keys(lfu.Ordered()); len(ks) != 2 || ks[0] != "bar" || ks[1] != "foo" {
+		t.Errorf("Invalid LFU order %v", ks)
+	}
+
+	ttl := cache.NewTTL(10)
+	ttl.Set("foo", 1, cache.Never())
+	ttl.Set("bar", 2, ttl.Exp(time.Hour))
+	ttl.Set("baz", 3, ttl.Exp(time.Minute))
+	ttl.Set("qux", 4, ttl.Exp(-time.Minute))
+	if ks := keys(ttl.Ordered()); len(ks) != 3 || ks[0] != "baz" || ks[1] != "bar" || ks[2] != "foo" {
+		t.Errorf("Invalid TTL order %v", ks)
+	}
+}
@@ -0,0 +1,93 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+	"github.com/alxarch/go-cache/cachetest"
+)
+
+func Test_Jitter(t *testing.T) {
+	if _, err := cache.Build(cache.WithJitter(1)); err != cache.ErrInvalidTTL {
+		t.Errorf("Invalid error %v", err)
+	}
+	clock := cachetest.NewClock(time.Unix(1000, 0))
+	c, err := cache.Build(cache.WithClock(clock), cache.WithDefaultTTL(time.Minute), cache.WithJitter(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc := c.(*cache.Cache)
+	seen := make(map[time.Time]bool)
+	for i := 0; i < 100; i++ {
+		cc.Set(i, i, time.Time{})
+		_, exp, _ := cc.Peek(i)
+		if exp.Before(time.Unix(1030, 0)) || exp.After(time.Unix(1060, 0)) {
+			t.Errorf("Invalid exp %s", exp)
+		}
+		seen[exp] = true
+	}
+	if len(seen) < 50 {
+		t.Errorf("Expiration times not jittered %d", len(seen))
+	}
+	cc.Set("foo", 1, cache.Never())
+	if _, exp, _ := cc.Peek("foo"); !exp.IsZero() {
+		t.Errorf("Never jittered %s", exp)
+	}
+	// Counters keep their expiration time
+	cc.Incr("bar", 1, 0)
+	_, exp, _ := cc.Peek("bar")
+	for i := 0; i < 10; i++ {
+		cc.Incr("bar", 1, 0)
+	}
+	if _, e, _ := cc.Peek("bar"); !e.Equal(exp) {
+		t.Errorf("Incr changed exp %s != %s", e, exp)
+	}
+}
+
+func Test_ProxyJitter(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(1000, 0))
+	c, _ := cache.Build(cache.WithClock(clock))
+	p := cache.Proxy(cache.UpstreamFunc(func(x interface{}) (interface{}, time.Time, error) {
+		return x, time.Unix(1100, 0), nil
+	}), c, cache.WithLoadJitter(0.2))
+	for i := 0; i < 10; i++ {
+		_, exp, err := p.Get(i)
+		if err != nil || exp.Before(time.Unix(1080, 0)) || exp.After(time.Unix(1100, 0)) {
+			t.Errorf("Invalid exp %s %v", exp, err)
+		}
+		if _, e, _ := c.Get(i); !e.Equal(exp) {
+			t.Errorf("Invalid stored exp %s", e)
+		}
+	}
+}
+
+func Test_EarlyRefresh(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(1000, 0))
+	c, _ := cache.Build(cache.WithClock(clock))
+	loads := 0
+	p := cache.Proxy(cache.UpstreamFunc(func(x interface{}) (interface{}, time.Time, error) {
+		loads++
+		// Loads take a second
+		clock.Advance(time.Second)
+		return loads, clock.Now().Add(time.Hour), nil
+	}), c, cache.WithEarlyRefresh(1))
+	p.Get("foo")
+	for i := 0; i < 10; i++ {
+		if v, _, _ := p.Get("foo"); v != 1 {
+			t.Errorf("Refreshed too early %v", v)
+		}
+	}
+	clock.Advance(time.Hour - time.Millisecond)
+	refreshed := false
+	for i := 0; i < 20 && !refreshed; i++ {
+		v, exp, err := p.Get("foo")
+		if err != nil || v == 1 && exp.Before(clock.Now()) {
+			t.Errorf("Invalid value %v %s %v", v, exp, err)
+		}
+		refreshed = v == 2
+	}
+	if !refreshed {
+		t.Error("Not refreshed before expiration")
+	}
+}
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"iter"
 	"sort"
 	"sync"
 	"time"
@@ -10,7 +11,7 @@ const DefaultLFUQueueSize = 100
 
 type LFU struct {
 	*Cache
-	pending  chan interface{}
+	pending  *accessBuffer
 	requests map[interface{}]uint64
 	mu       sync.Mutex
 }
@@ -24,11 +25,17 @@ func NewLFU(size int) *LFU {
 	if size <= 0 {
 		return nil
 	}
-	return &LFU{
-		Cache:    New(size),
-		requests: make(map[interface{}]uint64, size),
-		pending:  make(chan interface{}, size),
+	return newLFU(New(size), size)
+}
+
+func newLFU(c *Cache, queueSize int) *LFU {
+	lfu := &LFU{
+		Cache:    c,
+		requests: make(map[interface{}]uint64),
+		pending:  newAccessBuffer(queueSize),
 	}
+	c.policy = lfu
+	return lfu
 }
 
 func (c *LFU) Flush() {
@@ -38,26 +45,20 @@ func (c *LFU) Flush() {
 }
 
 func (c *LFU) flush() {
-	for {
-		select {
-		case p := <-c.pending:
-			c.requests[p] += 1
-		default:
-			return
+	c.pending.drain(func(k interface{}) {
+		if _, ok := c.requests[k]; ok {
+			c.requests[k]++
 		}
-	}
+	})
 }
 
+// Get records hits without waiting for the policy lock.
+// Hits may be dropped under contention and are applied in batches when a buffer fills up.
 func (c *LFU) Get(x interface{}) (y interface{}, exp time.Time, err error) {
 	y, exp, err = c.Cache.Get(x)
-	if err == nil {
-		select {
-		case c.pending <- x:
-			// pass
-		default:
-			c.Flush()
-			c.pending <- x
-		}
+	if err == nil && c.pending.record(x) && c.mu.TryLock() {
+		c.flush()
+		c.mu.Unlock()
 	}
 	return
 }
@@ -91,44 +92,87 @@ func (c *LFU) lfus() []lfu {
 }
 
 func (c *LFU) Set(x, y interface{}, exp time.Time) (err error) {
+	exp = c.Cache.expires(exp)
 	c.mu.Lock()
-	if err = c.Cache.Set(x, y, exp); err != ErrMaxSize {
+	err = c.setLocked(x, y, exp)
+	c.mu.Unlock()
+	return
+}
+
+func (c *LFU) setLocked(x, y interface{}, exp time.Time) (err error) {
+	if err = c.Cache.set(x, y, exp); err != ErrMaxSize {
 		if err == nil {
 			if _, ok := c.requests[x]; !ok {
 				c.requests[x] = 0
 			}
 		}
-		c.mu.Unlock()
 		return
 	}
 	lfus := c.lfus()
+	admitted := false
 	for _, lfu := range lfus {
+		if c.Cache.protected(lfu.Key) {
+			continue
+		}
+		if !admitted {
+			if err = c.Cache.admit(x, y, lfu.Key); err != nil {
+				return
+			}
+			admitted = true
+		}
 		delete(c.requests, lfu.Key)
-		c.Cache.Evict(lfu.Key)
-		if err = c.Cache.Set(x, y, exp); err != ErrMaxSize {
+		c.Cache.discard(lfu.Key)
+		if err = c.Cache.set(x, y, exp); err != ErrMaxSize {
 			if err == nil {
 				if _, ok := c.requests[x]; !ok {
 					c.requests[x] = 0
 				}
 			}
-			c.mu.Unlock()
 			return
 		}
 	}
-
-	c.mu.Unlock()
 	return
 }
 
 func (c *LFU) Evict(keys ...interface{}) int {
 	c.mu.Lock()
+	n := c.evictLocked(keys)
+	c.mu.Unlock()
+	return n
+}
+
+func (c *LFU) evictLocked(keys []interface{}) int {
 	c.flush()
 	for _, k := range keys {
 		delete(c.requests, k)
 	}
-	n := c.Cache.Evict(keys...)
-	c.mu.Unlock()
-	return n
+	return c.Cache.Evict(keys...)
+}
+
+func (c *LFU) lockPolicy()   { c.mu.Lock() }
+func (c *LFU) unlockPolicy() { c.mu.Unlock() }
+
+// Ordered returns an iterator over a snapshot of the items from the least to the most frequently used.
+func (c *LFU) Ordered() iter.Seq2[interface{}, Item] {
+	return c.Cache.ordered()
+}
+
+func (c *LFU) orderLocked() []interface{} {
+	lfus := c.lfus()
+	keys := make([]interface{}, len(lfus))
+	for i := range lfus {
+		keys[i] = lfus[i].Key
+	}
+	return keys
+}
+
+func (c *LFU) forgetLocked(k interface{}) {
+	delete(c.requests, k)
+}
+
+func (c *LFU) resetLocked() {
+	c.flush()
+	c.requests = make(map[interface{}]uint64)
 }
 
 func (c *LFU) Trim(now time.Time) []interface{} {
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrQueueFull is returned by Limit if all fetch slots and queue positions are taken.
+	ErrQueueFull = errors.New("Upstream queue full.")
+	// ErrQueueTimeout is returned by Limit if a queued request did not get a fetch slot in time.
+	ErrQueueTimeout = errors.New("Upstream queue timeout.")
+)
+
+type limitedUpstream struct {
+	Upstream
+	slots   chan struct{}
+	queue   chan struct{}
+	timeout time.Duration
+	// gauges
+	inFlight, queued int64
+	dropped          uint64
+}
+
+// Limit allows at most concurrency simultaneous requests to up.
+// Up to queue more requests wait for a free slot for at most timeout, a zero timeout waits indefinitely.
+// Requests that find the queue full fail with ErrQueueFull and requests that wait too long with ErrQueueTimeout.
+// Wrap a Limit with Blocking or Proxy so that requests for the same key take a single slot.
+// The returned Upstream also implements Metrics() Metrics reporting in-flight, queued and dropped requests.
+func Limit(up Upstream, concurrency, queue int, timeout time.Duration) Upstream {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if queue < 0 {
+		queue = 0
+	}
+	return &limitedUpstream{
+		Upstream: up,
+		slots:    make(chan struct{}, concurrency),
+		queue:    make(chan struct{}, queue),
+		timeout:  timeout,
+	}
+}
+
+func (l *limitedUpstream) Get(x interface{}) (interface{}, time.Time, error) {
+	if err := l.acquire(); err != nil {
+		atomic.AddUint64(&l.dropped, 1)
+		return nil, time.Time{}, err
+	}
+	atomic.AddInt64(&l.inFlight, 1)
+	defer func() {
+		atomic.AddInt64(&l.inFlight, -1)
+		<-l.slots
+	}()
+	return l.Upstream.Get(x)
+}
+
+func (l *limitedUpstream) acquire() error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	select {
+	case l.queue <- struct{}{}:
+	default:
+		return ErrQueueFull
+	}
+	atomic.AddInt64(&l.queued, 1)
+	defer func() {
+		atomic.AddInt64(&l.queued, -1)
+		<-l.queue
+	}()
+	if l.timeout <= 0 {
+		l.slots <- struct{}{}
+		return nil
+	}
+	timer := time.NewTimer(l.timeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrQueueTimeout
+	}
+}
+
+// Metrics reports in-flight, queued and dropped requests.
+func (l *limitedUpstream) Metrics() (m Metrics) {
+	m.InFlight = uint64(atomic.LoadInt64(&l.inFlight))
+	m.Queued = uint64(atomic.LoadInt64(&l.queued))
+	m.Dropped = atomic.LoadUint64(&l.dropped)
+	return
+}
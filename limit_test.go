@@ -0,0 +1,69 @@
+package cache_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+func Test_Limit(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{}, 10)
+	up := cache.Limit(cache.UpstreamFunc(func(x interface{}) (interface{}, time.Time, error) {
+		started <- struct{}{}
+		<-release
+		return x, time.Time{}, nil
+	}), 2, 1, 20*time.Millisecond)
+	metrics := up.(interface{ Metrics() cache.Metrics })
+	wg := sync.WaitGroup{}
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if v, _, err := up.Get(i); err != nil || v != i {
+				t.Errorf("Invalid value %v %v", v, err)
+			}
+		}(i)
+	}
+	<-started
+	<-started
+	if m := metrics.Metrics(); m.InFlight != 2 || m.Queued != 0 {
+		t.Errorf("Invalid metrics %#v", m)
+	}
+	// A third request is queued and times out, a fourth finds the queue full
+	timedOut := make(chan error)
+	go func() {
+		_, _, err := up.Get(2)
+		timedOut <- err
+	}()
+	for metrics.Metrics().Queued != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, _, err := up.Get(3); err != cache.ErrQueueFull {
+		t.Errorf("Invalid error %v", err)
+	}
+	if err := <-timedOut; err != cache.ErrQueueTimeout {
+		t.Errorf("Invalid error %v", err)
+	}
+	// Queued requests take the first free slot
+	queued := make(chan error)
+	go func() {
+		_, _, err := up.Get(4)
+		queued <- err
+	}()
+	for metrics.Metrics().Queued != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	release <- struct{}{}
+	<-started
+	close(release)
+	if err := <-queued; err != nil {
+		t.Errorf("Invalid error %v", err)
+	}
+	wg.Wait()
+	if m := metrics.Metrics(); m.InFlight != 0 || m.Queued != 0 || m.Dropped != 2 {
+		t.Errorf("Invalid metrics %#v", m)
+	}
+}
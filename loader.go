@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrInvalidValue is returned by LoadingCache if a cached value is not of the expected type.
+var ErrInvalidValue = errors.New("Invalid value type.")
+
+// Codec converts values to the form they are stored in cache, e.g. to share a cache of []byte values.
+type Codec[V any] interface {
+	Encode(v V) (interface{}, error)
+	Decode(x interface{}) (V, error)
+}
+
+// Loader declares how a LoadingCache loads values.
+type Loader[K comparable, V any] struct {
+	// Load fetches the value of a key.
+	Load func(key K) (V, error)
+	// TTL returns the time to live of a loaded value.
+	// If TTL is nil or returns zero or less the default TTL of the cache is used.
+	TTL func(key K, value V) time.Duration
+	// Normalize returns the canonical form of a key before lookups, e.g. lowercase emails.
+	Normalize func(key K) K
+	// Codec encodes values stored in cache, if nil values are stored as is.
+	Codec Codec[V]
+}
+
+// LoadingCache is a typed read-through cache.
+// Concurrent loads of the same key are merged like in Proxy.
+// Use a Namespace to share a cache between loaders with overlapping keys.
+type LoadingCache[K comparable, V any] struct {
+	loader  Loader[K, V]
+	cache   Interface
+	clock   Clock
+	counted *counted
+	proxy   Upstream
+}
+
+// NewLoadingCache returns a LoadingCache that stores values in c.
+// Its metrics only count lookups and loads of the LoadingCache.
+func NewLoadingCache[K comparable, V any](c Interface, l Loader[K, V], options ...ProxyOption) *LoadingCache[K, V] {
+	lc := &LoadingCache[K, V]{
+		loader:  l,
+		cache:   c,
+		clock:   clockOf(c),
+		counted: &counted{Interface: c},
+	}
+	lc.proxy = Proxy(UpstreamFunc(lc.load), lc.counted, options...)
+	return lc
+}
+
+func (lc *LoadingCache[K, V]) load(x interface{}) (interface{}, time.Time, error) {
+	key := x.(K)
+	v, err := lc.loader.Load(key)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var exp time.Time
+	if lc.loader.TTL != nil {
+		if ttl := lc.loader.TTL(key, v); ttl > 0 {
+			exp = lc.clock.Now().Add(ttl)
+		}
+	}
+	y, err := lc.encode(v)
+	return y, exp, err
+}
+
+func (lc *LoadingCache[K, V]) key(key K) K {
+	if lc.loader.Normalize != nil {
+		return lc.loader.Normalize(key)
+	}
+	return key
+}
+
+func (lc *LoadingCache[K, V]) encode(v V) (interface{}, error) {
+	if lc.loader.Codec != nil {
+		return lc.loader.Codec.Encode(v)
+	}
+	return v, nil
+}
+
+func (lc *LoadingCache[K, V]) decode(x interface{}) (v V, err error) {
+	if lc.loader.Codec != nil {
+		return lc.loader.Codec.Decode(x)
+	}
+	v, ok := x.(V)
+	if !ok {
+		err = ErrInvalidValue
+	}
+	return
+}
+
+// Get returns the value of a key loading it if it is not in cache.
+func (lc *LoadingCache[K, V]) Get(key K) (v V, err error) {
+	x, _, err := lc.proxy.Get(lc.key(key))
+	if err != nil {
+		return
+	}
+	return lc.decode(x)
+}
+
+// Set stores a value in cache.
+func (lc *LoadingCache[K, V]) Set(key K, v V, exp time.Time) error {
+	x, err := lc.encode(v)
+	if err != nil {
+		return err
+	}
+	return lc.counted.Set(lc.key(key), x, exp)
+}
+
+// Evict removes keys from cache.
+func (lc *LoadingCache[K, V]) Evict(keys ...K) {
+	xs := make([]interface{}, len(keys))
+	for i, k := range keys {
+		xs[i] = lc.key(k)
+	}
+	lc.cache.Evict(xs...)
+}
+
+// Metrics returns the hits, misses and load metrics of the LoadingCache.
+func (lc *LoadingCache[K, V]) Metrics() Metrics {
+	return lc.proxy.(*proxy).Metrics()
+}
+
+// counted is a view of a cache that counts its own lookups.
+type counted struct {
+	Interface
+	hit, miss, set uint64
+}
+
+func (c *counted) Get(x interface{}) (y interface{}, exp time.Time, err error) {
+	y, exp, err = c.Interface.Get(x)
+	if err == nil {
+		atomic.AddUint64(&c.hit, 1)
+	} else {
+		atomic.AddUint64(&c.miss, 1)
+	}
+	return
+}
+
+// Peek forwards to the cache so that Proxy reports stored expiration times.
+func (c *counted) Peek(x interface{}) (interface{}, time.Time, error) {
+	if p, ok := c.Interface.(peekable); ok {
+		return p.Peek(x)
+	}
+	return nil, time.Time{}, errors.ErrUnsupported
+}
+
+func (c *counted) Set(x, y interface{}, exp time.Time) (err error) {
+	if err = c.Interface.Set(x, y, exp); err == nil {
+		atomic.AddUint64(&c.set, 1)
+	}
+	return
+}
+
+func (c *counted) Clock() Clock {
+	return clockOf(c.Interface)
+}
+
+func (c *counted) Metrics() (m Metrics) {
+	m.Hit = atomic.LoadUint64(&c.hit)
+	m.Miss = atomic.LoadUint64(&c.miss)
+	m.Set = atomic.LoadUint64(&c.set)
+	return
+}
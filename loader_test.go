@@ -0,0 +1,116 @@
+package cache_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+	"github.com/alxarch/go-cache/cachetest"
+)
+
+type token struct {
+	Value  string
+	Expiry time.Time
+}
+
+type bytesCodec struct{}
+
+func (bytesCodec) Encode(s string) (interface{}, error) {
+	return []byte(s), nil
+}
+
+func (bytesCodec) Decode(x interface{}) (string, error) {
+	b, ok := x.([]byte)
+	if !ok {
+		return "", cache.ErrInvalidValue
+	}
+	return string(b), nil
+}
+
+func Test_LoadingCache(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(1000, 0))
+	c, err := cache.Build(cache.WithCapacity(10), cache.WithPolicy(cache.PolicyLRU), cache.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loads int64
+	errNotFound := errors.New("Not found")
+	tokens := cache.NewLoadingCache(c, cache.Loader[string, token]{
+		Load: func(user string) (token, error) {
+			atomic.AddInt64(&loads, 1)
+			if user == "nobody" {
+				return token{}, errNotFound
+			}
+			return token{"secret:" + user, clock.Now().Add(time.Minute)}, nil
+		},
+		TTL: func(_ string, tok token) time.Duration {
+			return tok.Expiry.Sub(clock.Now())
+		},
+		Normalize: strings.ToLower,
+	})
+	if tok, err := tokens.Get("Foo"); err != nil || tok.Value != "secret:foo" {
+		t.Errorf("Invalid token %v %v", tok, err)
+	}
+	if tok, err := tokens.Get("foo"); err != nil || tok.Value != "secret:foo" || loads != 1 {
+		t.Errorf("Invalid token %v %v %d", tok, err, loads)
+	}
+	if _, exp, err := c.Get("foo"); err != nil || !exp.Equal(time.Unix(1060, 0)) {
+		t.Errorf("Invalid exp %s %v", exp, err)
+	}
+	clock.Advance(time.Minute)
+	if _, err := tokens.Get("FOO"); err != nil || loads != 2 {
+		t.Errorf("Expired token not reloaded %v %d", err, loads)
+	}
+	if _, err := tokens.Get("nobody"); err != errNotFound {
+		t.Errorf("Invalid error %v", err)
+	}
+	c.Set("bar", 42, time.Time{})
+	if _, err := tokens.Get("bar"); err != cache.ErrInvalidValue {
+		t.Errorf("Invalid error %v", err)
+	}
+	if m := tokens.Metrics(); m.Hit != 2 || m.Miss != 3 || m.Load != 3 || m.LoadError != 1 || m.Set != 2 {
+		t.Errorf("Invalid metrics %#v", m)
+	}
+}
+
+func Test_LoadingCacheCodec(t *testing.T) {
+	c := cache.NewLRU(10)
+	var loads int64
+	names := cache.NewLoadingCache(c.Namespace("names"), cache.Loader[int, string]{
+		Load: func(id int) (string, error) {
+			atomic.AddInt64(&loads, 1)
+			time.Sleep(10 * time.Millisecond)
+			return strings.Repeat("x", id), nil
+		},
+		Codec: bytesCodec{},
+	})
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s, err := names.Get(3); err != nil || s != "xxx" {
+				t.Errorf("Invalid value %q %v", s, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if loads != 1 {
+		t.Errorf("Loads not merged %d", loads)
+	}
+	if v, _, err := c.Get(cache.NamespaceKey{Namespace: "names", Key: 3}); err != nil || string(v.([]byte)) != "xxx" {
+		t.Errorf("Invalid stored value %v %v", v, err)
+	}
+	names.Set(4, "yyyy", time.Time{})
+	if s, err := names.Get(4); err != nil || s != "yyyy" || loads != 1 {
+		t.Errorf("Invalid value %q %v", s, err)
+	}
+	names.Evict(4)
+	if s, err := names.Get(4); err != nil || s != "xxxx" || loads != 2 {
+		t.Errorf("Invalid value %q %v", s, err)
+	}
+}
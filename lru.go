@@ -2,6 +2,7 @@ package cache
 
 import (
 	"container/list"
+	"iter"
 	"sync"
 	"time"
 )
@@ -11,7 +12,7 @@ const DefaultLRUQueueSize = 100
 type LRU struct {
 	*Cache
 	list    *list.List
-	pending chan interface{}
+	pending *accessBuffer
 	index   map[interface{}]*list.Element
 
 	// Protects index and list
@@ -20,16 +21,22 @@ type LRU struct {
 
 func NewLRU(size int) (c *LRU) {
 	if size > 0 {
-		c = &LRU{
-			Cache:   New(size),
-			index:   make(map[interface{}]*list.Element),
-			list:    list.New(),
-			pending: make(chan interface{}, size),
-		}
+		c = newLRU(New(size), size)
 	}
 	return
 }
 
+func newLRU(c *Cache, queueSize int) *LRU {
+	lru := &LRU{
+		Cache:   c,
+		index:   make(map[interface{}]*list.Element),
+		list:    list.New(),
+		pending: newAccessBuffer(queueSize),
+	}
+	c.policy = lru
+	return lru
+}
+
 func (c *LRU) Flush() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -37,65 +44,78 @@ func (c *LRU) Flush() {
 }
 
 func (c *LRU) flush() {
-	for {
-		select {
-		case p := <-c.pending:
-			if el := c.index[p]; el != nil {
-				c.list.MoveToFront(el)
-			}
-		default:
-			return
+	c.pending.drain(func(k interface{}) {
+		if el := c.index[k]; el != nil {
+			c.list.MoveToFront(el)
 		}
-	}
+	})
 }
 
+// Get records hits without waiting for the policy lock.
+// Hits may be dropped under contention and are applied in batches when a buffer fills up.
 func (c *LRU) Get(x interface{}) (y interface{}, exp time.Time, err error) {
 	y, exp, err = c.Cache.Get(x)
-	if err == nil {
-		select {
-		case c.pending <- x:
-			// pass
-		default:
-			// Max pending changes in queue, reorder list
-			c.Flush()
-			c.pending <- x
-		}
+	if err == nil && c.pending.record(x) && c.mu.TryLock() {
+		c.flush()
+		c.mu.Unlock()
 	}
 	return
 }
 
 func (c *LRU) Set(x, y interface{}, exp time.Time) (err error) {
-	flushed := false
+	exp = c.Cache.expires(exp)
 	c.mu.Lock()
+	err = c.setLocked(x, y, exp)
+	c.mu.Unlock()
+	return
+}
+
+func (c *LRU) setLocked(x, y interface{}, exp time.Time) (err error) {
+	flushed, admitted := false, false
+	var next *list.Element
 	for {
-		if err = c.Cache.Set(x, y, exp); err != ErrMaxSize {
+		if err = c.Cache.set(x, y, exp); err != ErrMaxSize {
 			if err == nil {
 				if _, ok := c.index[x]; !ok {
 					c.index[x] = c.list.PushBack(x)
 				}
 			}
-			c.mu.Unlock()
 			return
 		}
 		if !flushed {
 			c.flush()
 			flushed = true
+			next = c.list.Back()
 		}
 		// Evict elements until we have an open position for the new element
-		if el := c.list.Back(); el != nil {
-			k := c.list.Remove(el)
-			delete(c.index, k)
-			c.Cache.Evict(k)
-		} else {
-			break
+		el := next
+		for el != nil && c.Cache.protected(el.Value) {
+			el = el.Prev()
 		}
+		if el == nil {
+			return
+		}
+		if !admitted {
+			if err = c.Cache.admit(x, y, el.Value); err != nil {
+				return
+			}
+			admitted = true
+		}
+		next = el.Prev()
+		k := c.list.Remove(el)
+		delete(c.index, k)
+		c.Cache.discard(k)
 	}
-	c.mu.Unlock()
-	return
 }
 
 func (c *LRU) Evict(keys ...interface{}) int {
 	c.mu.Lock()
+	n := c.evictLocked(keys)
+	c.mu.Unlock()
+	return n
+}
+
+func (c *LRU) evictLocked(keys []interface{}) int {
 	c.flush()
 	for _, k := range keys {
 		if el := c.index[k]; el != nil {
@@ -103,9 +123,37 @@ func (c *LRU) Evict(keys ...interface{}) int {
 			delete(c.index, k)
 		}
 	}
-	n := c.Cache.Evict(keys...)
-	c.mu.Unlock()
-	return n
+	return c.Cache.Evict(keys...)
+}
+
+func (c *LRU) lockPolicy()   { c.mu.Lock() }
+func (c *LRU) unlockPolicy() { c.mu.Unlock() }
+
+// Ordered returns an iterator over a snapshot of the items in eviction order.
+func (c *LRU) Ordered() iter.Seq2[interface{}, Item] {
+	return c.Cache.ordered()
+}
+
+func (c *LRU) orderLocked() []interface{} {
+	c.flush()
+	keys := make([]interface{}, 0, c.list.Len())
+	for el := c.list.Back(); el != nil; el = el.Prev() {
+		keys = append(keys, el.Value)
+	}
+	return keys
+}
+
+func (c *LRU) forgetLocked(k interface{}) {
+	if el := c.index[k]; el != nil {
+		c.list.Remove(el)
+		delete(c.index, k)
+	}
+}
+
+func (c *LRU) resetLocked() {
+	c.flush()
+	c.index = make(map[interface{}]*list.Element)
+	c.list.Init()
 }
 
 // Trim removes expired pairs from the cache and LRU list
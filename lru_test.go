@@ -117,3 +117,35 @@ func Test_LRU(t *testing.T) {
 	}
 
 }
+
+func Test_LRUParallelGet(t *testing.T) {
+	c := cache.NewLRU(100)
+	for i := 0; i < 100; i++ {
+		c.Set(i, i, time.Time{})
+	}
+	done := make(chan struct{})
+	for g := 0; g < 8; g++ {
+		go func(g int) {
+			defer func() { done <- struct{}{} }()
+			for i := 0; i < 10000; i++ {
+				if v, _, err := c.Get(i % 10); err != nil || v != i%10 {
+					t.Errorf("Invalid value %v %v", v, err)
+					return
+				}
+				if i%100 == g {
+					c.Set(100+i, i, time.Time{})
+				}
+			}
+		}(g)
+	}
+	for g := 0; g < 8; g++ {
+		<-done
+	}
+	c.Flush()
+	// Hot keys survive eviction by new keys
+	for i := 0; i < 10; i++ {
+		if _, _, err := c.Get(i); err != nil {
+			t.Errorf("Hot key %d evicted", i)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of Metrics.LoadLatency histogram buckets.
+// The last bucket of LoadLatency counts loads slower than all bounds.
+var LatencyBuckets = [...]time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Metrics is a snapshot of cache counters.
+type Metrics struct {
+	Hit, Miss, Evict, Expired, Items uint64
+	// Set counts successful calls to Set.
+	Set uint64
+	// EvictCapacity counts items evicted to make room for new ones and
+	// EvictExplicit items removed by calls to Evict. Evict is their sum.
+	EvictCapacity, EvictExplicit uint64
+	// Load and LoadError count upstream loads by Proxy and how many of them failed.
+	Load, LoadError uint64
+	// Shared counts calls to Blocking that were served by a load already in flight.
+	Shared uint64
+	// Rejected counts Proxy misses answered by a Filter without a load and
+	// FalsePositive loads of keys that passed the Filter but were not found upstream.
+	Rejected, FalsePositive uint64
+	// InFlight and Queued are gauges of requests fetching from and waiting for a Limit upstream.
+	// Dropped counts requests that failed with ErrQueueFull or ErrQueueTimeout.
+	InFlight, Queued, Dropped uint64
+	// LoadTime is the cumulative duration of upstream loads.
+	LoadTime time.Duration
+	// LoadLatency is a histogram of upstream load durations with LatencyBuckets bounds.
+	LoadLatency [len(LatencyBuckets) + 1]uint64
+}
+
+// HitRatio returns the ratio of hits to total lookups or zero if there were no lookups.
+func (m Metrics) HitRatio() float64 {
+	if total := m.Hit + m.Miss; total > 0 {
+		return float64(m.Hit) / float64(total)
+	}
+	return 0
+}
+
+// Delta returns the change in counters since a previous snapshot.
+// Items, InFlight and Queued are not counters and are kept as is.
+func (m Metrics) Delta(prev Metrics) Metrics {
+	m.Hit -= prev.Hit
+	m.Miss -= prev.Miss
+	m.Evict -= prev.Evict
+	m.Expired -= prev.Expired
+	m.Set -= prev.Set
+	m.EvictCapacity -= prev.EvictCapacity
+	m.EvictExplicit -= prev.EvictExplicit
+	m.Load -= prev.Load
+	m.LoadError -= prev.LoadError
+	m.Shared -= prev.Shared
+	m.Rejected -= prev.Rejected
+	m.FalsePositive -= prev.FalsePositive
+	m.Dropped -= prev.Dropped
+	m.LoadTime -= prev.LoadTime
+	for i := range m.LoadLatency {
+		m.LoadLatency[i] -= prev.LoadLatency[i]
+	}
+	return m
+}
+
+func (m Metrics) add(o Metrics) Metrics {
+	m.Hit += o.Hit
+	m.Miss += o.Miss
+	m.Evict += o.Evict
+	m.Expired += o.Expired
+	m.Items += o.Items
+	m.Set += o.Set
+	m.EvictCapacity += o.EvictCapacity
+	m.EvictExplicit += o.EvictExplicit
+	m.Load += o.Load
+	m.LoadError += o.LoadError
+	m.Shared += o.Shared
+	m.Rejected += o.Rejected
+	m.FalsePositive += o.FalsePositive
+	m.InFlight += o.InFlight
+	m.Queued += o.Queued
+	m.Dropped += o.Dropped
+	m.LoadTime += o.LoadTime
+	for i := range m.LoadLatency {
+		m.LoadLatency[i] += o.LoadLatency[i]
+	}
+	return m
+}
+
+// Metrics returns a snapshot of the cache metrics.
+func (c *Cache) Metrics() (m Metrics) {
+	m.Hit = atomic.LoadUint64(&c.metrics.Hit)
+	m.Miss = atomic.LoadUint64(&c.metrics.Miss)
+	m.Set = atomic.LoadUint64(&c.metrics.Set)
+	m.EvictCapacity = atomic.LoadUint64(&c.metrics.EvictCapacity)
+	m.EvictExplicit = atomic.LoadUint64(&c.metrics.EvictExplicit)
+	m.Evict = m.EvictCapacity + m.EvictExplicit
+	m.Expired = atomic.LoadUint64(&c.metrics.Expired)
+	c.mu.RLock()
+	m.Items = uint64(len(c.values))
+	c.mu.RUnlock()
+	return
+}
+
+// ResetMetrics zeroes the cache counters and returns their values before the reset.
+func (c *Cache) ResetMetrics() (m Metrics) {
+	m.Hit = atomic.SwapUint64(&c.metrics.Hit, 0)
+	m.Miss = atomic.SwapUint64(&c.metrics.Miss, 0)
+	m.Set = atomic.SwapUint64(&c.metrics.Set, 0)
+	m.EvictCapacity = atomic.SwapUint64(&c.metrics.EvictCapacity, 0)
+	m.EvictExplicit = atomic.SwapUint64(&c.metrics.EvictExplicit, 0)
+	m.Evict = m.EvictCapacity + m.EvictExplicit
+	m.Expired = atomic.SwapUint64(&c.metrics.Expired, 0)
+	c.mu.RLock()
+	m.Items = uint64(len(c.values))
+	c.mu.RUnlock()
+	return
+}
+
+// loadMetrics records upstream loads.
+type loadMetrics struct {
+	load, loadError         uint64
+	rejected, falsePositive uint64
+	loadTime                int64
+	latency                 [len(LatencyBuckets) + 1]uint64
+}
+
+func (l *loadMetrics) observe(d time.Duration, err error) {
+	atomic.AddUint64(&l.load, 1)
+	if err != nil {
+		atomic.AddUint64(&l.loadError, 1)
+	}
+	atomic.AddInt64(&l.loadTime, int64(d))
+	i := 0
+	for ; i < len(LatencyBuckets); i++ {
+		if d <= LatencyBuckets[i] {
+			break
+		}
+	}
+	atomic.AddUint64(&l.latency[i], 1)
+}
+
+func (l *loadMetrics) read(m *Metrics) {
+	m.Load = atomic.LoadUint64(&l.load)
+	m.LoadError = atomic.LoadUint64(&l.loadError)
+	m.LoadTime = time.Duration(atomic.LoadInt64(&l.loadTime))
+	m.Rejected = atomic.LoadUint64(&l.rejected)
+	m.FalsePositive = atomic.LoadUint64(&l.falsePositive)
+	for i := range m.LoadLatency {
+		m.LoadLatency[i] = atomic.LoadUint64(&l.latency[i])
+	}
+}
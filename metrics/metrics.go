@@ -0,0 +1,194 @@
+// Package metrics exports cache metrics in Prometheus text format and via expvar.
+package metrics
+
+import (
+	"bufio"
+	"encoding/json"
+	"expvar"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+// Source is implemented by all caches.
+type Source interface {
+	Metrics() cache.Metrics
+}
+
+type sample struct {
+	suffix string
+	labels string
+	value  float64
+}
+
+type family struct {
+	name    string
+	help    string
+	typ     string
+	samples func(m *cache.Metrics) []sample
+}
+
+func value(v func(m *cache.Metrics) float64) func(m *cache.Metrics) []sample {
+	return func(m *cache.Metrics) []sample {
+		return []sample{{value: v(m)}}
+	}
+}
+
+var families = []family{
+	{"cache_hits_total", "Number of lookups that found a fresh value.", "counter", value(func(m *cache.Metrics) float64 { return float64(m.Hit) })},
+	{"cache_misses_total", "Number of lookups that found no value or an expired one.", "counter", value(func(m *cache.Metrics) float64 { return float64(m.Miss) })},
+	{"cache_sets_total", "Number of successful calls to Set.", "counter", value(func(m *cache.Metrics) float64 { return float64(m.Set) })},
+	{"cache_evictions_total", "Number of evicted items by reason.", "counter", func(m *cache.Metrics) []sample {
+		return []sample{
+			{labels: `reason="capacity"`, value: float64(m.EvictCapacity)},
+			{labels: `reason="explicit"`, value: float64(m.EvictExplicit)},
+		}
+	}},
+	{"cache_expired_total", "Number of expired items removed.", "counter", value(func(m *cache.Metrics) float64 { return float64(m.Expired) })},
+	{"cache_items", "Number of items in cache.", "gauge", value(func(m *cache.Metrics) float64 { return float64(m.Items) })},
+	{"cache_hit_ratio", "Ratio of hits to lookups.", "gauge", value(func(m *cache.Metrics) float64 { return m.HitRatio() })},
+	{"cache_loads_total", "Number of upstream loads.", "counter", value(func(m *cache.Metrics) float64 { return float64(m.Load) })},
+	{"cache_load_errors_total", "Number of failed upstream loads.", "counter", value(func(m *cache.Metrics) float64 { return float64(m.LoadError) })},
+	{"cache_shared_total", "Number of lookups served by a load already in flight.", "counter", value(func(m *cache.Metrics) float64 { return float64(m.Shared) })},
+	{"cache_filter_rejections_total", "Number of misses rejected by a filter without a load.", "counter", value(func(m *cache.Metrics) float64 { return float64(m.Rejected) })},
+	{"cache_upstream_in_flight", "Number of upstream requests in flight.", "gauge", value(func(m *cache.Metrics) float64 { return float64(m.InFlight) })},
+	{"cache_upstream_queued", "Number of upstream requests waiting for a slot.", "gauge", value(func(m *cache.Metrics) float64 { return float64(m.Queued) })},
+	{"cache_upstream_dropped_total", "Number of upstream requests dropped by a full queue or a queue timeout.", "counter", value(func(m *cache.Metrics) float64 { return float64(m.Dropped) })},
+	{"cache_filter_false_positives_total", "Number of loads of keys that passed a filter but were not found.", "counter", value(func(m *cache.Metrics) float64 { return float64(m.FalsePositive) })},
+	{"cache_load_duration_seconds", "Duration of upstream loads.", "histogram", func(m *cache.Metrics) []sample {
+		samples := make([]sample, 0, len(m.LoadLatency)+2)
+		n := uint64(0)
+		for i, count := range m.LoadLatency {
+			n += count
+			le := "+Inf"
+			if i < len(cache.LatencyBuckets) {
+				le = strconv.FormatFloat(cache.LatencyBuckets[i].Seconds(), 'g', -1, 64)
+			}
+			samples = append(samples, sample{"_bucket", `le="` + le + `"`, float64(n)})
+		}
+		return append(samples,
+			sample{"_sum", "", m.LoadTime.Seconds()},
+			sample{"_count", "", float64(n)},
+		)
+	}},
+}
+
+// Collector collects metrics from named caches.
+// It implements http.Handler serving Prometheus text format and expvar.Var.
+type Collector struct {
+	mu      sync.RWMutex
+	sources map[string]Source
+}
+
+// NewCollector returns an empty Collector.
+func NewCollector() *Collector {
+	return &Collector{
+		sources: make(map[string]Source),
+	}
+}
+
+// Register adds a cache under a name, replacing any cache with the same name.
+func (c *Collector) Register(name string, src Source) {
+	c.mu.Lock()
+	c.sources[name] = src
+	c.mu.Unlock()
+}
+
+// Unregister removes a named cache.
+func (c *Collector) Unregister(name string) {
+	c.mu.Lock()
+	delete(c.sources, name)
+	c.mu.Unlock()
+}
+
+type snapshot struct {
+	name    string
+	metrics cache.Metrics
+}
+
+func (c *Collector) collect() []snapshot {
+	c.mu.RLock()
+	snapshots := make([]snapshot, 0, len(c.sources))
+	for name, src := range c.sources {
+		snapshots = append(snapshots, snapshot{name, src.Metrics()})
+	}
+	c.mu.RUnlock()
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].name < snapshots[j].name
+	})
+	return snapshots
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes metrics of all caches in Prometheus text exposition format.
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	snapshots := c.collect()
+	bw := bufio.NewWriter(w)
+	for i := range families {
+		f := &families[i]
+		bw.WriteString("# HELP " + f.name + " " + f.help + "\n")
+		bw.WriteString("# TYPE " + f.name + " " + f.typ + "\n")
+		for j := range snapshots {
+			s := &snapshots[j]
+			label := `cache="` + labelEscaper.Replace(s.name) + `"`
+			for _, sample := range f.samples(&s.metrics) {
+				bw.WriteString(f.name + sample.suffix + "{" + label)
+				if sample.labels != "" {
+					bw.WriteString("," + sample.labels)
+				}
+				bw.WriteString("} " + strconv.FormatFloat(sample.value, 'g', -1, 64) + "\n")
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP implements http.Handler.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WritePrometheus(w)
+}
+
+// String implements expvar.Var returning a JSON object with metrics for each cache.
+func (c *Collector) String() string {
+	snapshots := c.collect()
+	vars := make(map[string]map[string]float64, len(snapshots))
+	for i := range snapshots {
+		vars[snapshots[i].name] = values(&snapshots[i].metrics)
+	}
+	data, _ := json.Marshal(vars)
+	return string(data)
+}
+
+// values flattens samples into a map for expvar, histogram buckets are omitted.
+func values(m *cache.Metrics) map[string]float64 {
+	v := make(map[string]float64, len(families))
+	for i := range families {
+		f := &families[i]
+		for _, sample := range f.samples(m) {
+			if sample.suffix == "_bucket" {
+				continue
+			}
+			key := strings.TrimPrefix(f.name, "cache_") + sample.suffix
+			if sample.labels != "" {
+				key += "_" + strings.Trim(sample.labels[strings.IndexByte(sample.labels, '=')+1:], `"`)
+			}
+			v[key] = sample.value
+		}
+	}
+	return v
+}
+
+// Var returns an expvar.Var for a single cache.
+func Var(src Source) expvar.Var {
+	return expvar.Func(func() interface{} {
+		m := src.Metrics()
+		return values(&m)
+	})
+}
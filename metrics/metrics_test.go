@@ -0,0 +1,73 @@
+package metrics_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+	"github.com/alxarch/go-cache/metrics"
+)
+
+func Test_Collector(t *testing.T) {
+	lru := cache.NewLRU(1)
+	lru.Set("foo", "bar", cache.Never())
+	lru.Get("foo")
+	lru.Get("foo")
+	lru.Get("bar")
+	lru.Set("bar", "baz", cache.Never())
+	c := metrics.NewCollector()
+	c.Register("lru", lru)
+	c.Register(`"fifo"`, cache.NewFIFO(1))
+	p := cache.Proxy(cache.UpstreamFunc(func(x interface{}) (interface{}, time.Time, error) {
+		return x, cache.Never(), nil
+	}), cache.NewLRU(1))
+	p.Get("foo")
+	c.Register("proxy", p.(metrics.Source))
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, line := range []string{
+		"# TYPE cache_hits_total counter",
+		`cache_hits_total{cache="lru"} 2`,
+		`cache_hits_total{cache="\"fifo\""} 0`,
+		`cache_misses_total{cache="lru"} 1`,
+		`cache_evictions_total{cache="lru",reason="capacity"} 1`,
+		`cache_evictions_total{cache="lru",reason="explicit"} 0`,
+		`cache_sets_total{cache="lru"} 2`,
+		`cache_loads_total{cache="proxy"} 1`,
+		`cache_load_duration_seconds_bucket{cache="proxy",le="+Inf"} 1`,
+		`cache_load_duration_seconds_count{cache="proxy"} 1`,
+		`cache_filter_rejections_total{cache="proxy"} 0`,
+		`cache_items{cache="lru"} 1`,
+		`cache_hit_ratio{cache="lru"} 0.6666666666666666`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Missing line %q in\n%s", line, body)
+		}
+	}
+
+	var vars map[string]map[string]float64
+	if err := json.Unmarshal([]byte(c.String()), &vars); err != nil {
+		t.Fatal(err)
+	}
+	if v := vars["lru"]["hits_total"]; v != 2 {
+		t.Errorf("Invalid expvar hits %f", v)
+	}
+	if v := vars["lru"]["evictions_total_capacity"]; v != 1 {
+		t.Errorf("Invalid expvar evictions %f", v)
+	}
+	c.Unregister("lru")
+	if strings.Contains(c.String(), "lru") {
+		t.Errorf("Cache not unregistered")
+	}
+
+	var v expvar.Var = metrics.Var(lru)
+	if s := v.String(); !strings.Contains(s, `"items":1`) {
+		t.Errorf("Invalid var %s", s)
+	}
+}
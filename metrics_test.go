@@ -0,0 +1,66 @@
+package cache_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+func Test_Metrics(t *testing.T) {
+	c := cache.NewLRU(1)
+	c.Set("foo", "bar", cache.Never())
+	c.Set("bar", "baz", cache.Never())
+	c.Evict("bar")
+	m := c.Metrics()
+	if m.Set != 2 || m.EvictCapacity != 1 || m.EvictExplicit != 1 || m.Evict != 2 {
+		t.Errorf("Invalid metrics %#v", m)
+	}
+	c.Set("foo", "bar", cache.Never())
+	if d := c.Metrics().Delta(m); d.Set != 1 || d.Evict != 0 || d.Items != 1 {
+		t.Errorf("Invalid delta %#v", d)
+	}
+	if prev := c.ResetMetrics(); prev.Set != 3 {
+		t.Errorf("Invalid metrics before reset %#v", prev)
+	}
+	if m := c.Metrics(); m.Set != 0 || m.Evict != 0 || m.Items != 1 {
+		t.Errorf("Invalid metrics after reset %#v", m)
+	}
+
+	errFail := errors.New("fail")
+	release := make(chan struct{})
+	p := cache.Proxy(cache.UpstreamFunc(func(x interface{}) (interface{}, time.Time, error) {
+		<-release
+		if x == "fail" {
+			return nil, time.Time{}, errFail
+		}
+		return x, cache.Never(), nil
+	}), cache.New(0))
+	done := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		go func() {
+			p.Get("foo")
+			done <- struct{}{}
+		}()
+	}
+	for p.(interface{ Metrics() cache.Metrics }).Metrics().Shared == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	<-done
+	<-done
+	p.Get("foo")
+	p.Get("fail")
+	m = p.(interface{ Metrics() cache.Metrics }).Metrics()
+	if m.Load != 2 || m.LoadError != 1 || m.Shared != 1 || m.Hit != 1 || m.Set != 1 {
+		t.Errorf("Invalid proxy metrics %#v", m)
+	}
+	n := uint64(0)
+	for _, count := range m.LoadLatency {
+		n += count
+	}
+	if n != 2 {
+		t.Errorf("Invalid latency histogram %v", m.LoadLatency)
+	}
+}
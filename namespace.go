@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// NamespaceKey is the key under which a Namespace stores items in its cache.
+// Eviction hooks and iterators see keys set through a Namespace as NamespaceKey.
+type NamespaceKey struct {
+	Namespace string
+	Key       interface{}
+}
+
+// NamespaceOption configures a Namespace.
+type NamespaceOption func(ns *namespace)
+
+// WithQuota sets the minimum and maximum weight of items in a namespace.
+// Eviction policies do not evict items of a namespace below its minimum
+// and items set in a namespace over its maximum evict items of the same namespace,
+// in eviction order or in no particular order for caches without an eviction policy.
+// Zero values disable the respective quota.
+func WithQuota(min, max int) NamespaceOption {
+	return func(ns *namespace) {
+		ns.min, ns.max = min, max
+	}
+}
+
+// namespace is the shared state of Namespace views, guarded by the cache lock
+// except for metrics which are updated atomically.
+type namespace struct {
+	min, max int
+	weight   int
+	metrics  Metrics
+}
+
+// Namespace is a view of a cache with keys isolated from other namespaces.
+// All namespaces share the capacity and eviction policy of the cache.
+type Namespace struct {
+	cache Interface
+	base  *Cache
+	name  string
+	ns    *namespace
+}
+
+var _ Interface = (*Namespace)(nil)
+
+// Namespace returns a view of a cache for a namespace.
+// Views of the same name share quotas and metrics, options replace the quotas of previous views.
+func (c *Cache) Namespace(name string, options ...NamespaceOption) *Namespace {
+	var front Interface = c
+	if p, ok := c.policy.(Interface); ok {
+		front = p
+	}
+	c.mu.Lock()
+	if c.namespaces == nil {
+		c.namespaces = make(map[string]*namespace)
+	}
+	ns := c.namespaces[name]
+	if ns == nil {
+		ns = &namespace{}
+		c.namespaces[name] = ns
+	}
+	for _, option := range options {
+		option(ns)
+	}
+	c.mu.Unlock()
+	return &Namespace{
+		cache: front,
+		base:  c,
+		name:  name,
+		ns:    ns,
+	}
+}
+
+// Get returns a value of the namespace.
+func (n *Namespace) Get(k interface{}) (v interface{}, exp time.Time, err error) {
+	v, exp, err = n.cache.Get(NamespaceKey{n.name, k})
+	if err == nil {
+		atomic.AddUint64(&n.ns.metrics.Hit, 1)
+	} else {
+		atomic.AddUint64(&n.ns.metrics.Miss, 1)
+	}
+	return
+}
+
+// Set assigns a value to a key of the namespace.
+// If the namespace has a maximum quota items of the namespace are evicted to make room for it.
+func (n *Namespace) Set(k, v interface{}, exp time.Time) error {
+	key := NamespaceKey{n.name, k}
+	c := n.base
+	c.mu.RLock()
+	max := n.ns.max
+	c.mu.RUnlock()
+	if max <= 0 {
+		return n.cache.Set(key, v, exp)
+	}
+	w := c.weigh(key, v)
+	if w > max {
+		// Do not evict items of the namespace for an item that never fits
+		return ErrMaxSize
+	}
+	exp = c.expires(exp)
+	p := c.policy
+	if p == nil {
+		c.mu.Lock()
+		defer c.unlock()
+		// Without a policy there is no eviction order so items of the namespace are evicted in no particular order
+		for victim, e := range c.values {
+			if !c.overQuota(key, w) {
+				break
+			}
+			if nk, ok := victim.(NamespaceKey); !ok || nk.Namespace != n.name || nk == key {
+				continue
+			}
+			c.remove(victim, e, ReasonCapacity)
+			atomic.AddUint64(&c.metrics.EvictCapacity, 1)
+		}
+		if c.overQuota(key, w) {
+			return ErrMaxSize
+		}
+		return c.insert(key, v, exp, w)
+	}
+	p.lockPolicy()
+	defer p.unlockPolicy()
+	c.mu.RLock()
+	over := c.overQuota(key, w)
+	c.mu.RUnlock()
+	if over {
+		for _, victim := range p.orderLocked() {
+			if nk, ok := victim.(NamespaceKey); !ok || nk.Namespace != n.name || victim == interface{}(key) {
+				continue
+			}
+			p.forgetLocked(victim)
+			c.discard(victim)
+			c.mu.RLock()
+			over = c.overQuota(key, w)
+			c.mu.RUnlock()
+			if !over {
+				break
+			}
+		}
+		if over {
+			return ErrMaxSize
+		}
+	}
+	return p.setLocked(key, v, exp)
+}
+
+// Evict removes keys of the namespace and returns the size of the cache.
+func (n *Namespace) Evict(keys ...interface{}) int {
+	nk := make([]interface{}, len(keys))
+	for i, k := range keys {
+		nk[i] = NamespaceKey{n.name, k}
+	}
+	return n.cache.Evict(nk...)
+}
+
+// Weight returns the total weight of items in the namespace.
+func (n *Namespace) Weight() (w int) {
+	n.base.mu.RLock()
+	w = n.ns.weight
+	n.base.mu.RUnlock()
+	return
+}
+
+// Metrics returns the metrics of the namespace.
+// Items is the number of items in the namespace.
+func (n *Namespace) Metrics() (m Metrics) {
+	ns := &n.ns.metrics
+	m.Hit = atomic.LoadUint64(&ns.Hit)
+	m.Miss = atomic.LoadUint64(&ns.Miss)
+	m.Set = atomic.LoadUint64(&ns.Set)
+	m.EvictCapacity = atomic.LoadUint64(&ns.EvictCapacity)
+	m.EvictExplicit = atomic.LoadUint64(&ns.EvictExplicit)
+	m.Evict = m.EvictCapacity + m.EvictExplicit
+	m.Expired = atomic.LoadUint64(&ns.Expired)
+	m.Items = atomic.LoadUint64(&ns.Items)
+	return
+}
+
+// overQuota reports whether setting a key would exceed the maximum quota of its namespace.
+// The caller must hold the lock.
+func (c *Cache) overQuota(k NamespaceKey, w int) bool {
+	ns := c.namespaces[k.Namespace]
+	weight := ns.weight + w
+	if old := c.values[k]; old != nil {
+		weight -= old.weight
+	}
+	return weight > ns.max
+}
+
+// protected reports whether evicting a key would shrink its namespace below its minimum quota.
+func (c *Cache) protected(k interface{}) bool {
+	nk, ok := k.(NamespaceKey)
+	if !ok {
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ns, e := c.namespaces[nk.Namespace], c.values[k]
+	return ns != nil && e != nil && ns.weight-e.weight < ns.min
+}
+
+// account updates the namespace of a key when an entry is added or removed.
+// The caller must hold the lock.
+func (c *Cache) account(k interface{}, weight int, items int64) *namespace {
+	nk, ok := k.(NamespaceKey)
+	if !ok {
+		return nil
+	}
+	ns := c.namespaces[nk.Namespace]
+	if ns == nil {
+		return nil
+	}
+	ns.weight += weight
+	atomic.AddUint64(&ns.metrics.Items, uint64(items))
+	return ns
+}
@@ -0,0 +1,90 @@
+package cache_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+func Test_Namespace(t *testing.T) {
+	c := cache.NewFIFO(4)
+	users := c.Namespace("users")
+	posts := c.Namespace("posts")
+	users.Set("foo", 1, time.Time{})
+	posts.Set("foo", 2, time.Time{})
+	if v, _, err := users.Get("foo"); err != nil || v != 1 {
+		t.Errorf("Invalid value %v %v", v, err)
+	}
+	if v, _, err := posts.Get("foo"); err != nil || v != 2 {
+		t.Errorf("Invalid value %v %v", v, err)
+	}
+	if _, _, err := users.Get("bar"); err != cache.ErrKeyNotFound {
+		t.Errorf("Invalid error %v", err)
+	}
+	if _, _, err := c.Get("foo"); err != cache.ErrKeyNotFound {
+		t.Errorf("Invalid error %v", err)
+	}
+	users.Evict("foo")
+	if m := users.Metrics(); m.Hit != 1 || m.Miss != 1 || m.Set != 1 || m.EvictExplicit != 1 || m.Items != 0 {
+		t.Errorf("Invalid metrics %#v", m)
+	}
+	if m := posts.Metrics(); m.Hit != 1 || m.Set != 1 || m.Items != 1 {
+		t.Errorf("Invalid metrics %#v", m)
+	}
+}
+
+func Test_NamespaceQuota(t *testing.T) {
+	for _, policy := range policies[1:] {
+		c, err := cache.Build(cache.WithCapacity(6), cache.WithPolicy(policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+		users := c.(interface {
+			Namespace(name string, options ...cache.NamespaceOption) *cache.Namespace
+		}).Namespace("users", cache.WithQuota(2, 3))
+		for i := 0; i < 5; i++ {
+			if err := users.Set(i, i, time.Time{}); err != nil {
+				t.Errorf("%s: Invalid error %v", policy, err)
+			}
+		}
+		if w := users.Weight(); w != 3 {
+			t.Errorf("%s: Invalid weight %d", policy, w)
+		}
+		if m := users.Metrics(); m.EvictCapacity != 2 || m.Items != 3 {
+			t.Errorf("%s: Invalid metrics %#v", policy, m)
+		}
+		// Other keys evict anything but the minimum quota of users
+		for i := 0; i < 10; i++ {
+			c.Set(fmt.Sprint(i), i, cache.Exp(time.Duration(i+1)*time.Hour))
+		}
+		// FIFO evicts the oldest keys first, other policies may keep more users
+		if w := users.Weight(); w < 2 || policy == cache.PolicyFIFO && w != 2 {
+			t.Errorf("%s: Invalid weight %d", policy, w)
+		}
+		if n := c.Evict(); n != 6 {
+			t.Errorf("%s: Invalid size %d", policy, n)
+		}
+	}
+}
+
+func Test_NamespaceQuotaNoPolicy(t *testing.T) {
+	c := cache.New(0)
+	users := c.Namespace("users", cache.WithQuota(0, 3))
+	c.Set("foo", "bar", time.Time{})
+	for i := 0; i < 5; i++ {
+		if err := users.Set(i, i, time.Time{}); err != nil {
+			t.Errorf("Invalid error %v", err)
+		}
+	}
+	if v, _, err := users.Get(4); err != nil || v != 4 {
+		t.Errorf("Invalid value %v %v", v, err)
+	}
+	if m := users.Metrics(); m.EvictCapacity != 2 || m.Items != 3 {
+		t.Errorf("Invalid metrics %#v", m)
+	}
+	if n := c.Evict(); n != 4 {
+		t.Errorf("Invalid size %d", n)
+	}
+}
@@ -0,0 +1,60 @@
+package cache
+
+import "time"
+
+// Peek returns a value assigned to a key and its expiration time like Get
+// without updating metrics, sliding expiration or the eviction policy.
+func (c *Cache) Peek(k interface{}) (v interface{}, exp time.Time, err error) {
+	c.mu.RLock()
+	e, ok := c.values[k]
+	if !ok {
+		c.mu.RUnlock()
+		err = ErrKeyNotFound
+		return
+	}
+	v, exp = e.value, e.exp
+	c.mu.RUnlock()
+	if !exp.IsZero() && !exp.After(c.clock.Now()) {
+		err = ErrExpired
+	}
+	return
+}
+
+// TTL returns the remaining lifetime of a key.
+// Keys without expiration return a negative duration.
+// It has no side effects like Peek.
+func (c *Cache) TTL(k interface{}) (time.Duration, error) {
+	_, exp, err := c.Peek(k)
+	if err != nil {
+		return 0, err
+	}
+	if exp.IsZero() {
+		return -1, nil
+	}
+	return exp.Sub(c.clock.Now()), nil
+}
+
+// Touch sets the expiration time of a key without rewriting its value.
+// The expiration time is handled as in Set and is capped by the max lifetime of the key.
+// It reports whether the key was found and had not expired.
+func (c *Cache) Touch(k interface{}, exp time.Time) bool {
+	_, ok := c.touch(k, c.expires(exp))
+	return ok
+}
+
+// touch updates the expiration time of a fresh key and returns the stored expiration.
+func (c *Cache) touch(k interface{}, exp time.Time) (time.Time, bool) {
+	now := c.clock.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.values[k]
+	if e == nil || !e.exp.IsZero() && !e.exp.After(now) {
+		return exp, false
+	}
+	if !e.limit.IsZero() && !exp.IsZero() && exp.After(e.limit) {
+		exp = e.limit
+	}
+	e.exp = exp
+	c.schedule(e)
+	return exp, true
+}
@@ -0,0 +1,71 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+	"github.com/alxarch/go-cache/cachetest"
+)
+
+func Test_Peek(t *testing.T) {
+	lru := cache.NewLRU(2)
+	lru.Set("foo", 1, time.Time{})
+	lru.Set("bar", 2, time.Time{})
+	lru.Get("bar")
+	lru.Get("foo")
+	if v, exp, err := lru.Peek("bar"); err != nil || v != 2 || !exp.IsZero() {
+		t.Errorf("Invalid Peek %v %s %v", v, exp, err)
+	}
+	if _, _, err := lru.Peek("baz"); err != cache.ErrKeyNotFound {
+		t.Errorf("Invalid error %v", err)
+	}
+	if m := lru.Metrics(); m.Hit != 2 || m.Miss != 0 {
+		t.Errorf("Peek changed metrics %#v", m)
+	}
+	// Peek did not make bar recently used
+	lru.Set("baz", 3, time.Time{})
+	if _, _, err := lru.Peek("bar"); err != cache.ErrKeyNotFound {
+		t.Errorf("Invalid error %v", err)
+	}
+	if d, err := lru.TTL("foo"); err != nil || d >= 0 {
+		t.Errorf("Invalid TTL %s %v", d, err)
+	}
+}
+
+func Test_Touch(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(1000, 0))
+	c, err := cache.Build(cache.WithCapacity(2), cache.WithPolicy(cache.PolicyTTL), cache.WithClock(clock), cache.WithSlidingTTL(time.Minute, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ttl := c.(*cache.TTL)
+	ttl.Set("foo", 1, time.Time{})
+	ttl.Set("bar", 2, ttl.Exp(time.Second))
+	if d, err := ttl.TTL("foo"); err != nil || d != time.Minute {
+		t.Errorf("Invalid TTL %s %v", d, err)
+	}
+	clock.Advance(30 * time.Second)
+	if d, err := ttl.TTL("foo"); err != nil || d != 30*time.Second {
+		t.Errorf("TTL has side effects %s %v", d, err)
+	}
+	if _, err := ttl.TTL("bar"); err != cache.ErrExpired {
+		t.Errorf("Invalid error %v", err)
+	}
+	if ttl.Touch("bar", ttl.Exp(time.Minute)) {
+		t.Error("Touched expired key")
+	}
+	if !ttl.Touch("foo", ttl.Exp(2*time.Hour)) {
+		t.Error("Touch failed")
+	}
+	if d, _ := ttl.TTL("foo"); d != time.Hour-30*time.Second {
+		t.Errorf("Touch not capped by lifetime %s", d)
+	}
+	ttl.Touch("foo", ttl.Exp(time.Second))
+	// foo is now the soonest to expire and is evicted first
+	ttl.Set("bar", 2, ttl.Exp(time.Minute))
+	ttl.Set("baz", 3, ttl.Exp(time.Minute))
+	if _, _, err := ttl.Peek("foo"); err != cache.ErrKeyNotFound {
+		t.Errorf("Invalid error %v", err)
+	}
+}
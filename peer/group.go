@@ -0,0 +1,89 @@
+package peer
+
+import (
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+// Group is a cache namespace distributed among peers.
+// Each key is loaded from upstream only by the peer that owns it.
+type Group struct {
+	name   string
+	peers  Picker
+	main   cache.Interface
+	hot    cache.Interface
+	local  cache.Upstream
+	remote cache.Upstream
+}
+
+// NewGroup returns a Group that loads keys it owns from up and stores them in main.
+// Values fetched from other peers are stored in hot if it is not nil.
+func NewGroup(name string, up cache.Upstream, main, hot cache.Interface, peers Picker) *Group {
+	if peers == nil {
+		peers = NoPeers{}
+	}
+	g := &Group{
+		name:  name,
+		peers: peers,
+		main:  main,
+		hot:   hot,
+		local: cache.Proxy(up, main),
+	}
+	g.remote = cache.Blocking(cache.UpstreamFunc(g.fetch))
+	return g
+}
+
+// Name returns the name of the group.
+func (g *Group) Name() string {
+	return g.name
+}
+
+// Get implements cache.Upstream.
+// Keys must be strings.
+func (g *Group) Get(x interface{}) (y interface{}, exp time.Time, err error) {
+	key, ok := x.(string)
+	if !ok {
+		err = ErrInvalidKey
+		return
+	}
+	if y, exp, err = g.main.Get(key); err == nil {
+		return
+	}
+	if g.hot != nil {
+		if y, exp, err = g.hot.Get(key); err == nil {
+			return
+		}
+	}
+	if _, ok := g.peers.PickPeer(key); ok {
+		return g.remote.Get(key)
+	}
+	return g.local.Get(key)
+}
+
+// Local returns a key from the group loading it from upstream without asking other peers.
+// It is used by transports to serve requests from other peers.
+func (g *Group) Local(key string) (interface{}, time.Time, error) {
+	return g.local.Get(key)
+}
+
+func (g *Group) fetch(x interface{}) (y interface{}, exp time.Time, err error) {
+	key := x.(string)
+	p, ok := g.peers.PickPeer(key)
+	if !ok {
+		return g.local.Get(key)
+	}
+	y, exp, err = p.Fetch(g.name, key)
+	switch err {
+	case nil:
+		if g.hot != nil {
+			g.hot.Set(key, y, exp)
+		}
+		return
+	case cache.ErrKeyNotFound:
+		return
+	default:
+		// Peer is unreachable, load locally.
+		return g.local.Get(key)
+	}
+}
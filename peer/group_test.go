@@ -0,0 +1,108 @@
+package peer_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+	"github.com/alxarch/go-cache/peer"
+)
+
+func Test_Group(t *testing.T) {
+	var loads sync.Map
+	upstream := cache.UpstreamFunc(func(x interface{}) (interface{}, time.Time, error) {
+		n, _ := loads.LoadOrStore(x, new(int64))
+		atomic.AddInt64(n.(*int64), 1)
+		if x == "missing" {
+			return nil, time.Time{}, cache.ErrKeyNotFound
+		}
+		return "value:" + x.(string), time.Time{}, nil
+	})
+	const numPeers = 3
+	groups := make([]*peer.Group, numPeers)
+	hot := make([]*cache.LRU, numPeers)
+	urls := make([]string, numPeers)
+	pools := make([]*peer.HTTPPool, numPeers)
+	for i := range groups {
+		var handler http.Handler
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handler.ServeHTTP(w, r)
+		}))
+		defer srv.Close()
+		urls[i] = srv.URL
+		pools[i] = peer.NewHTTPPool(srv.URL)
+		handler = pools[i]
+		hot[i] = cache.NewLRU(10)
+		groups[i] = peer.NewGroup("test", upstream, cache.NewLRU(100), hot[i], pools[i])
+		pools[i].Register(groups[i])
+	}
+	for _, p := range pools {
+		p.Set(urls...)
+	}
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 10; i++ {
+		for _, g := range groups {
+			for k := 0; k < 20; k++ {
+				wg.Add(1)
+				go func(g *peer.Group, key string) {
+					defer wg.Done()
+					if v, _, err := g.Get(key); err != nil {
+						t.Errorf("Unexpected error %s", err)
+					} else if v != "value:"+key {
+						t.Errorf("Invalid value %v", v)
+					}
+				}(g, fmt.Sprintf("key%d", k))
+			}
+		}
+	}
+	wg.Wait()
+	loads.Range(func(k, n interface{}) bool {
+		if n := atomic.LoadInt64(n.(*int64)); n != 1 {
+			t.Errorf("Key %s loaded %d times", k, n)
+		}
+		return true
+	})
+	for i, g := range groups {
+		if _, _, err := g.Get("missing"); err != cache.ErrKeyNotFound {
+			t.Errorf("Invalid error %v", err)
+		}
+		if _, _, err := g.Get(42); err != peer.ErrInvalidKey {
+			t.Errorf("Invalid error %v", err)
+		}
+		if _, ok := pools[i].PickPeer("key0"); !ok {
+			if n := hot[i].Size(); n == 0 {
+				t.Errorf("Peer %d has no hot keys", i)
+			}
+		}
+	}
+}
+
+func Test_GroupHungPeer(t *testing.T) {
+	release := make(chan struct{})
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer hung.Close()
+	defer close(release)
+	pool := peer.NewHTTPPool("http://self")
+	pool.Set("http://self", hung.URL)
+	pool.Client = &http.Client{Timeout: 50 * time.Millisecond}
+	g := peer.NewGroup("test", cache.UpstreamFunc(func(x interface{}) (interface{}, time.Time, error) {
+		return "local:" + x.(string), time.Time{}, nil
+	}), cache.NewLRU(100), nil, pool)
+	key := ""
+	for i := 0; key == ""; i++ {
+		if _, ok := pool.PickPeer(fmt.Sprint("key", i)); ok {
+			key = fmt.Sprint("key", i)
+		}
+	}
+	// Requests to the hung owner time out and the key is loaded locally
+	if v, _, err := g.Get(key); err != nil || v != "local:"+key {
+		t.Errorf("Invalid Get %v %v", v, err)
+	}
+}
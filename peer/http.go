@@ -0,0 +1,161 @@
+package peer
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+// DefaultBasePath is the path prefix for peer requests.
+const DefaultBasePath = "/_cache/"
+
+// DefaultTimeout is the timeout for requests to peers if HTTPPool has no Client.
+const DefaultTimeout = 5 * time.Second
+
+// defaultClient gives up on hung peers so that Group loads keys locally.
+var defaultClient = &http.Client{Timeout: DefaultTimeout}
+
+// HTTPPool is a Picker that fetches keys from peers over HTTP.
+// It also serves requests from other peers for registered groups.
+// Values are encoded with encoding/gob so custom value types must be registered with gob.Register.
+type HTTPPool struct {
+	self     string
+	basePath string
+	replicas int
+	// Client is used for requests to peers, a client with DefaultTimeout is used if nil.
+	// Clients without a timeout block all lookups of a key while its owner hangs.
+	Client *http.Client
+
+	mu     sync.RWMutex
+	ring   *Ring
+	peers  map[string]*httpPeer
+	groups map[string]*Group
+}
+
+// NewHTTPPool returns an HTTPPool for the peer at base URL self (ie http://10.0.0.1:8080).
+func NewHTTPPool(self string) *HTTPPool {
+	return &HTTPPool{
+		self:     strings.TrimSuffix(self, "/"),
+		basePath: DefaultBasePath,
+		replicas: DefaultReplicas,
+		ring:     NewRing(DefaultReplicas, nil),
+		groups:   make(map[string]*Group),
+	}
+}
+
+// Set replaces the pool's peers, self should be included.
+func (p *HTTPPool) Set(peers ...string) {
+	ring := NewRing(p.replicas, nil)
+	index := make(map[string]*httpPeer, len(peers))
+	for _, base := range peers {
+		base = strings.TrimSuffix(base, "/")
+		ring.Add(base)
+		index[base] = &httpPeer{pool: p, baseURL: base + p.basePath}
+	}
+	p.mu.Lock()
+	p.ring, p.peers = ring, index
+	p.mu.Unlock()
+}
+
+// Register makes a group available to other peers.
+func (p *HTTPPool) Register(g *Group) {
+	p.mu.Lock()
+	p.groups[g.Name()] = g
+	p.mu.Unlock()
+}
+
+// PickPeer implements Picker.
+func (p *HTTPPool) PickPeer(key string) (Peer, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if owner := p.ring.Get(key); owner != "" && owner != p.self {
+		return p.peers[owner], true
+	}
+	return nil, false
+}
+
+type response struct {
+	Value interface{}
+	Exp   time.Time
+}
+
+// ServeHTTP implements http.Handler serving requests from other peers.
+func (p *HTTPPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, p.basePath) {
+		http.NotFound(w, r)
+		return
+	}
+	parts := strings.SplitN(r.URL.EscapedPath()[len(p.basePath):], "/", 2)
+	if len(parts) != 2 {
+		http.Error(w, "Invalid request path", http.StatusBadRequest)
+		return
+	}
+	name, err := url.PathUnescape(parts[0])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	key, err := url.PathUnescape(parts[1])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p.mu.RLock()
+	g := p.groups[name]
+	p.mu.RUnlock()
+	if g == nil {
+		http.Error(w, ErrUnknownGroup.Error(), http.StatusBadRequest)
+		return
+	}
+	v, exp, err := g.Local(key)
+	switch err {
+	case nil:
+	case cache.ErrKeyNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-gob")
+	if err := gob.NewEncoder(w).Encode(&response{v, exp}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type httpPeer struct {
+	pool    *HTTPPool
+	baseURL string
+}
+
+func (h *httpPeer) Fetch(group, key string) (value interface{}, exp time.Time, err error) {
+	client := h.pool.Client
+	if client == nil {
+		client = defaultClient
+	}
+	res, err := client.Get(h.baseURL + url.PathEscape(group) + "/" + url.PathEscape(key))
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+		r := response{}
+		if err = gob.NewDecoder(res.Body).Decode(&r); err == nil {
+			value, exp = r.Value, r.Exp
+		}
+	case http.StatusNotFound:
+		err = cache.ErrKeyNotFound
+	default:
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		err = fmt.Errorf("peer %s: %s: %s", h.baseURL, res.Status, strings.TrimSpace(string(msg)))
+	}
+	return
+}
@@ -0,0 +1,33 @@
+// Package peer implements a distributed cache where each key is owned by a single peer.
+package peer
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidKey is returned by Group.Get for keys that are not strings.
+	ErrInvalidKey = errors.New("Invalid key.")
+	// ErrUnknownGroup is returned by peers that have no group with the requested name.
+	ErrUnknownGroup = errors.New("Unknown group.")
+)
+
+// Peer fetches values from the group of a remote peer.
+type Peer interface {
+	Fetch(group, key string) (value interface{}, exp time.Time, err error)
+}
+
+// Picker picks the peer that owns a key.
+// It returns false if the key is owned by the local peer.
+type Picker interface {
+	PickPeer(key string) (Peer, bool)
+}
+
+// NoPeers is a Picker that owns all keys locally.
+type NoPeers struct{}
+
+// PickPeer implements Picker.
+func (NoPeers) PickPeer(string) (Peer, bool) {
+	return nil, false
+}
@@ -0,0 +1,75 @@
+package peer
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+)
+
+// HashFunc hashes data to a point on the ring.
+type HashFunc func(data []byte) uint32
+
+// DefaultReplicas is the default number of virtual nodes per peer.
+const DefaultReplicas = 50
+
+// Ring is a consistent hash ring with virtual nodes.
+// Ring is not safe for concurrent modification.
+type Ring struct {
+	hash     HashFunc
+	replicas int
+	points   []uint32
+	nodes    map[uint32]string
+}
+
+// NewRing returns a Ring with replicas virtual nodes per peer.
+// If hash is nil crc32.ChecksumIEEE is used.
+func NewRing(replicas int, hash HashFunc) *Ring {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+	if hash == nil {
+		hash = crc32.ChecksumIEEE
+	}
+	return &Ring{
+		hash:     hash,
+		replicas: replicas,
+		nodes:    make(map[uint32]string),
+	}
+}
+
+// Len returns the number of virtual nodes in the ring.
+func (r *Ring) Len() int {
+	return len(r.points)
+}
+
+// Add adds peers to the ring.
+func (r *Ring) Add(nodes ...string) {
+	for _, node := range nodes {
+		for i := 0; i < r.replicas; i++ {
+			h := r.hash([]byte(strconv.Itoa(i) + node))
+			if _, ok := r.nodes[h]; ok {
+				continue
+			}
+			r.points = append(r.points, h)
+			r.nodes[h] = node
+		}
+	}
+	sort.Slice(r.points, func(i, j int) bool {
+		return r.points[i] < r.points[j]
+	})
+}
+
+// Get returns the peer that owns a key.
+func (r *Ring) Get(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	h := r.hash([]byte(key))
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i] >= h
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.nodes[r.points[i]]
+}
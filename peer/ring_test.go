@@ -0,0 +1,37 @@
+package peer_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/alxarch/go-cache/peer"
+)
+
+func Test_Ring(t *testing.T) {
+	r := peer.NewRing(3, func(data []byte) uint32 {
+		n, _ := strconv.Atoi(string(data))
+		return uint32(n)
+	})
+	if owner := r.Get("1"); owner != "" {
+		t.Errorf("Invalid owner on empty ring %q", owner)
+	}
+	// Virtual nodes 2, 12, 22 / 4, 14, 24 / 6, 16, 26
+	r.Add("2", "4", "6")
+	if r.Len() != 9 {
+		t.Errorf("Invalid ring size %d", r.Len())
+	}
+	for key, owner := range map[string]string{
+		"1":  "2",
+		"11": "2",
+		"23": "4",
+		"27": "2",
+	} {
+		if o := r.Get(key); o != owner {
+			t.Errorf("Invalid owner for %s: %s != %s", key, o, owner)
+		}
+	}
+	r.Add("8")
+	if o := r.Get("27"); o != "8" {
+		t.Errorf("Invalid owner after add %s", o)
+	}
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// policy is implemented by eviction policies so that read-modify-write
+// operations on a Cache keep the policy bookkeeping in sync.
+type policy interface {
+	lockPolicy()
+	unlockPolicy()
+	// setLocked and evictLocked are called with the policy locked.
+	// setLocked expects a resolved expiration time.
+	setLocked(k, v interface{}, exp time.Time) error
+	evictLocked(keys []interface{}) int
+	// orderLocked returns the keys in eviction order.
+	orderLocked() []interface{}
+	// forgetLocked drops a key from the policy state only.
+	forgetLocked(k interface{})
+	// resetLocked drops all policy state.
+	resetLocked()
+}
+
+// Purge removes all items from the cache and resets the state of its eviction policy.
+// It returns the number of removed items.
+func (c *Cache) Purge() (n int) {
+	if p := c.policy; p != nil {
+		p.lockPolicy()
+		defer p.unlockPolicy()
+		p.resetLocked()
+	}
+	c.mu.Lock()
+	n = len(c.values)
+	for k, e := range c.values {
+		c.remove(k, e, ReasonExplicit)
+	}
+	atomic.AddUint64(&c.metrics.EvictExplicit, uint64(n))
+	c.unlock()
+	return
+}
+
+// Resize changes the capacity of the cache.
+// Caches with an eviction policy evict items in eviction order until they fit the new capacity
+// and return ErrInvalidCapacity if size is zero or less.
+// Caches without an eviction policy return ErrMaxSize if their items do not fit in the new capacity
+// and have no size limit if size is zero or less.
+func (c *Cache) Resize(size int) error {
+	p := c.policy
+	if p == nil {
+		if size < 0 {
+			size = 0
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if size > 0 && c.weight > size {
+			return ErrMaxSize
+		}
+		c.maxsize = size
+		return nil
+	}
+	if size <= 0 {
+		return ErrInvalidCapacity
+	}
+	p.lockPolicy()
+	defer p.unlockPolicy()
+	c.mu.Lock()
+	c.maxsize = size
+	fits := c.weight <= size
+	c.mu.Unlock()
+	if fits {
+		return nil
+	}
+	for _, k := range p.orderLocked() {
+		if c.protected(k) {
+			continue
+		}
+		p.forgetLocked(k)
+		c.discard(k)
+		if c.Weight() <= size {
+			break
+		}
+	}
+	return nil
+}
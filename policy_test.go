@@ -0,0 +1,87 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+type resizer interface {
+	cache.Interface
+	Purge() int
+	Resize(size int) error
+	Keys() []interface{}
+}
+
+func Test_Purge(t *testing.T) {
+	for _, policy := range policies {
+		evicted := 0
+		c, err := cache.Build(cache.WithCapacity(3), cache.WithPolicy(policy), cache.WithOnEvict(func(_, _ interface{}, reason cache.EvictReason) {
+			if reason == cache.ReasonExplicit {
+				evicted++
+			}
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := c.(resizer)
+		r.Set("foo", 1, time.Time{})
+		r.Set("bar", 2, time.Time{})
+		r.Get("foo")
+		if n := r.Purge(); n != 2 || evicted != 2 {
+			t.Errorf("%s: Invalid Purge %d %d", policy, n, evicted)
+		}
+		if n := r.Evict(); n != 0 {
+			t.Errorf("%s: Invalid size %d", policy, n)
+		}
+		// Policy state is reset so the cache can be filled again
+		for _, k := range []string{"foo", "bar", "baz", "qux"} {
+			r.Set(k, k, time.Time{})
+		}
+		if policy != cache.PolicyNone && r.Evict() != 3 {
+			t.Errorf("%s: Invalid size %d", policy, r.Evict())
+		}
+	}
+}
+
+func Test_Resize(t *testing.T) {
+	c := cache.NewFIFO(4)
+	for _, k := range []string{"foo", "bar", "baz", "qux"} {
+		c.Set(k, k, time.Time{})
+	}
+	if err := c.Resize(0); err != cache.ErrInvalidCapacity {
+		t.Errorf("Invalid error %v", err)
+	}
+	if err := c.Resize(2); err != nil {
+		t.Fatal(err)
+	}
+	if keys := c.Keys(); len(keys) != 2 || c.Cap() != 2 {
+		t.Errorf("Invalid keys %v", keys)
+	}
+	if _, _, err := c.Get("foo"); err != cache.ErrKeyNotFound {
+		t.Errorf("Oldest item not evicted %v", err)
+	}
+	if m := c.Metrics(); m.EvictCapacity != 2 {
+		t.Errorf("Invalid metrics %#v", m)
+	}
+	c.Set("foo", 1, time.Time{})
+	if n := c.Evict(); n != 2 {
+		t.Errorf("Invalid size %d", n)
+	}
+	c.Resize(3)
+	c.Set("bar", 1, time.Time{})
+	if n := c.Evict(); n != 3 {
+		t.Errorf("Invalid size %d", n)
+	}
+
+	base := cache.New(3)
+	base.Set("foo", 1, time.Time{})
+	base.Set("bar", 2, time.Time{})
+	if err := base.Resize(1); err != cache.ErrMaxSize {
+		t.Errorf("Invalid error %v", err)
+	}
+	if err := base.Resize(0); err != nil || base.Cap() != 0 {
+		t.Errorf("Invalid resize %v", err)
+	}
+}
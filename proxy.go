@@ -1,27 +1,181 @@
 package cache
 
 import (
+	"math"
+	"math/rand/v2"
+	"sync/atomic"
 	"time"
 )
 
 type proxy struct {
 	Upstream
-	Cache Interface
+	Cache    Interface
+	blocking *blockingUpstream
+	tracer   Tracer
+	filter   Filter
+	jitter   float64
+	beta     float64
+	clock    Clock
+	metrics  loadMetrics
 }
 
-func Proxy(u Upstream, c Interface) Upstream {
-	return &proxy{Blocking(u), c}
+// ProxyOption configures a Proxy.
+type ProxyOption func(p *proxy)
+
+// WithTracer traces proxy lookups and upstream loads.
+func WithTracer(t Tracer) ProxyOption {
+	return func(p *proxy) {
+		p.tracer = t
+		p.blocking.tracer = t
+	}
+}
+
+// WithFilter answers misses for keys rejected by f with ErrKeyNotFound without loading them.
+func WithFilter(f Filter) ProxyOption {
+	return func(p *proxy) {
+		p.filter = f
+	}
+}
+
+// WithLoadJitter shortens the expiration time of loaded values by a random fraction up to fraction of their TTL.
+func WithLoadJitter(fraction float64) ProxyOption {
+	return func(p *proxy) {
+		p.jitter = fraction
+	}
+}
+
+// WithEarlyRefresh reloads values before they expire with a probability that grows
+// as expiration approaches (XFetch). Larger beta values refresh earlier.
+// The refresh window is scaled by the average load duration and refreshes are merged like loads.
+func WithEarlyRefresh(beta float64) ProxyOption {
+	return func(p *proxy) {
+		p.beta = beta
+	}
+}
+
+// Proxy returns an Upstream that serves values from c and loads missing keys from u.
+// Load durations are measured with the Clock of c if it has one.
+// Concurrent loads of the same key are merged and the value is stored in c before
+// waiting callers are released.
+// The returned Upstream also implements Metrics() Metrics reporting cache and load metrics.
+func Proxy(u Upstream, c Interface, options ...ProxyOption) Upstream {
+	clock := clockOf(c)
+	p := &proxy{Cache: c, clock: clock}
+	p.blocking = newBlocking(UpstreamFunc(func(x interface{}) (y interface{}, exp time.Time, err error) {
+		start := clock.Now()
+		y, exp, err = u.Get(x)
+		p.metrics.observe(clock.Now().Sub(start), err)
+		if err == ErrKeyNotFound && p.filter != nil {
+			atomic.AddUint64(&p.metrics.falsePositive, 1)
+		}
+		if err == nil {
+			if !exp.Equal(never) {
+				exp = jitter(exp, clock.Now(), p.jitter)
+			}
+			if c.Set(x, y, exp) == nil {
+				exp = storedExp(c, x, exp)
+			} else if exp.Equal(never) {
+				exp = time.Time{}
+			}
+		}
+		return
+	}))
+	p.Upstream = p.blocking
+	for _, option := range options {
+		option(p)
+	}
+	return p
+}
+
+type peekable interface {
+	Peek(k interface{}) (interface{}, time.Time, error)
 }
 
-func ProxyFunc(u UpstreamFunc, c Interface) Upstream {
-	return &proxy{Blocking(u), c}
+// storedExp returns the expiration time of a key that was just set so that loads report it as cache hits do.
+// Caches resolve zero expiration times to their default TTL and store Never as a zero time.
+func storedExp(c Interface, k interface{}, exp time.Time) time.Time {
+	if p, ok := c.(peekable); ok {
+		if _, e, err := p.Peek(k); err == nil {
+			return e
+		}
+	}
+	if exp.Equal(never) {
+		return time.Time{}
+	}
+	return exp
+}
+
+func ProxyFunc(u UpstreamFunc, c Interface, options ...ProxyOption) Upstream {
+	return Proxy(u, c, options...)
 }
 
-func (p *proxy) Get(x interface{}) (y interface{}, exp time.Time, err error) {
-	if y, exp, err = p.Cache.Get(x); err == ErrKeyNotFound || err == ErrExpired {
-		if y, exp, err = p.Upstream.Get(x); err == nil {
-			p.Cache.Set(x, y, exp)
+func (p *proxy) Get(x interface{}) (interface{}, time.Time, error) {
+	if p.tracer == nil {
+		// Skip all span work so that cache hits do not allocate
+		return p.get(nil, x)
+	}
+	span := p.tracer.Start(nil, SpanGet)
+	y, exp, err := p.get(span, x)
+	span.End()
+	return y, exp, err
+}
+
+// get serves a lookup, span is nil if the proxy is not traced.
+func (p *proxy) get(span Span, x interface{}) (y interface{}, exp time.Time, err error) {
+	if span == nil {
+		y, exp, err = p.Cache.Get(x)
+	} else {
+		lookup := p.tracer.Start(span, SpanLookup)
+		y, exp, err = p.Cache.Get(x)
+		r := result(err)
+		lookup.SetAttribute(AttrResult, r)
+		lookup.End()
+		span.SetAttribute(AttrResult, r)
+	}
+	if err == ErrKeyNotFound || err == ErrExpired {
+		if p.filter != nil && !p.filter.Test(x) {
+			atomic.AddUint64(&p.metrics.rejected, 1)
+			if span != nil {
+				span.SetAttribute(AttrResult, "rejected")
+			}
+			return nil, time.Time{}, ErrKeyNotFound
+		}
+		if y, exp, err = p.blocking.trace(span, x); err != nil && span != nil {
+			span.SetAttribute(AttrError, err.Error())
+		}
+	} else if err == nil && p.refresh(exp) {
+		if span != nil {
+			span.SetAttribute(AttrResult, "refresh")
+		}
+		if v, e, rerr := p.blocking.trace(span, x); rerr == nil {
+			y, exp = v, e
+		} else if span != nil {
+			// Serve the value that has not expired yet
+			span.SetAttribute(AttrResult, "stale")
+			span.SetAttribute(AttrError, rerr.Error())
 		}
 	}
 	return
 }
+
+// Metrics returns the cache metrics along with upstream load metrics.
+func (p *proxy) Metrics() Metrics {
+	m := p.Cache.Metrics()
+	p.metrics.read(&m)
+	m.Shared = p.blocking.Metrics().Shared
+	return m
+}
+
+// refresh decides whether a value expiring at exp should be reloaded early.
+func (p *proxy) refresh(exp time.Time) bool {
+	if p.beta <= 0 || exp.IsZero() {
+		return false
+	}
+	n := atomic.LoadUint64(&p.metrics.load)
+	if n == 0 {
+		return false
+	}
+	delta := float64(atomic.LoadInt64(&p.metrics.loadTime)) / float64(n)
+	gap := time.Duration(-delta * p.beta * math.Log(1-rand.Float64()))
+	return !p.clock.Now().Add(gap).Before(exp)
+}
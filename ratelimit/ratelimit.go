@@ -0,0 +1,96 @@
+// Package ratelimit implements rate limiters that keep their counters in a cache.
+package ratelimit
+
+import (
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+// Store keeps the window counters of a limiter.
+// *cache.Cache and all caches with an eviction policy implement Store.
+type Store interface {
+	cache.Upstream
+	Incr(k interface{}, delta int64, ttl time.Duration) (int64, error)
+	Clock() cache.Clock
+}
+
+// windowKey is the key of a counter for a window starting at Start.
+type windowKey struct {
+	Key   interface{}
+	Start int64
+}
+
+// Limiter allows up to a number of events per key in a window.
+type Limiter interface {
+	// Allow reports whether an event for a key is allowed.
+	Allow(key interface{}) (bool, error)
+}
+
+// FixedWindow counts events in consecutive windows aligned to the store clock.
+// It allows bursts of up to twice the limit across a window boundary.
+type FixedWindow struct {
+	store  Store
+	limit  int64
+	window time.Duration
+}
+
+var _ Limiter = (*FixedWindow)(nil)
+
+// NewFixedWindow returns a limiter allowing limit events per key in each window.
+func NewFixedWindow(store Store, limit int64, window time.Duration) *FixedWindow {
+	return &FixedWindow{store, limit, window}
+}
+
+// Allow implements Limiter.
+func (l *FixedWindow) Allow(key interface{}) (bool, error) {
+	now := l.store.Clock().Now().UnixNano()
+	start := now - now%int64(l.window)
+	ttl := time.Duration(start + int64(l.window) - now)
+	n, err := l.store.Incr(windowKey{key, start}, 1, ttl)
+	if err != nil {
+		return false, err
+	}
+	return n <= l.limit, nil
+}
+
+// SlidingWindow estimates the events in the last window from the counters of the current and previous fixed windows.
+// The previous count is weighted by how much of the previous window overlaps the sliding one.
+type SlidingWindow struct {
+	store  Store
+	limit  int64
+	window time.Duration
+}
+
+var _ Limiter = (*SlidingWindow)(nil)
+
+// NewSlidingWindow returns a limiter allowing about limit events per key in any window.
+func NewSlidingWindow(store Store, limit int64, window time.Duration) *SlidingWindow {
+	return &SlidingWindow{store, limit, window}
+}
+
+// Allow implements Limiter.
+func (l *SlidingWindow) Allow(key interface{}) (bool, error) {
+	now := l.store.Clock().Now().UnixNano()
+	start := now - now%int64(l.window)
+	// Counters are kept for two windows so that they can be read as the previous one
+	ttl := time.Duration(start + 2*int64(l.window) - now)
+	cur := windowKey{key, start}
+	n, err := l.store.Incr(cur, 1, ttl)
+	if err != nil {
+		return false, err
+	}
+	var prev int64
+	if v, _, err := l.store.Get(windowKey{key, start - int64(l.window)}); err == nil {
+		prev, _ = v.(int64)
+	}
+	overlap := 1 - float64(now-start)/float64(l.window)
+	if float64(n)+float64(prev)*overlap <= float64(l.limit) {
+		return true, nil
+	}
+	// Denied events do not count against the next window
+	if _, err := l.store.Incr(cur, -1, ttl); err != nil {
+		return false, err
+	}
+	return false, nil
+}
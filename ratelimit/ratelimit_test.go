@@ -0,0 +1,87 @@
+package ratelimit_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+	"github.com/alxarch/go-cache/cachetest"
+	"github.com/alxarch/go-cache/ratelimit"
+)
+
+func newStore(t *testing.T, clock cache.Clock) ratelimit.Store {
+	c, err := cache.Build(cache.WithCapacity(100), cache.WithPolicy(cache.PolicyLRU), cache.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c.(ratelimit.Store)
+}
+
+func allowed(t *testing.T, l ratelimit.Limiter, key string, n int) (total int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		ok, err := l.Allow(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			total++
+		}
+	}
+	return
+}
+
+func Test_FixedWindow(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(1000, 0))
+	l := ratelimit.NewFixedWindow(newStore(t, clock), 3, time.Second)
+	if n := allowed(t, l, "foo", 5); n != 3 {
+		t.Errorf("Invalid allowed %d", n)
+	}
+	if n := allowed(t, l, "bar", 1); n != 1 {
+		t.Errorf("Invalid allowed %d", n)
+	}
+	clock.Advance(time.Second)
+	if n := allowed(t, l, "foo", 5); n != 3 {
+		t.Errorf("Invalid allowed %d", n)
+	}
+}
+
+func Test_FixedWindowConcurrent(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(1000, 0))
+	l := ratelimit.NewFixedWindow(newStore(t, clock), 50, time.Second)
+	var mu sync.Mutex
+	total := 0
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := allowed(t, l, "foo", 20)
+			mu.Lock()
+			total += n
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if total != 50 {
+		t.Errorf("Invalid allowed %d", total)
+	}
+}
+
+func Test_SlidingWindow(t *testing.T) {
+	clock := cachetest.NewClock(time.Unix(1000, 0))
+	l := ratelimit.NewSlidingWindow(newStore(t, clock), 4, time.Second)
+	if n := allowed(t, l, "foo", 6); n != 4 {
+		t.Errorf("Invalid allowed %d", n)
+	}
+	// Half of the previous window still counts
+	clock.Advance(1500 * time.Millisecond)
+	if n := allowed(t, l, "foo", 6); n != 2 {
+		t.Errorf("Invalid allowed %d", n)
+	}
+	clock.Advance(2 * time.Second)
+	if n := allowed(t, l, "foo", 6); n != 4 {
+		t.Errorf("Invalid allowed %d", n)
+	}
+}
@@ -0,0 +1,200 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+)
+
+var (
+	// ErrProtocol is returned when a client sends a malformed request.
+	ErrProtocol = errors.New("Protocol error.")
+)
+
+// MaxBulkSize is the maximum size of a bulk string accepted from clients.
+const MaxBulkSize = 512 << 20
+
+// MaxArgs is the maximum number of arguments of a request accepted from clients.
+const MaxArgs = 1024 * 1024
+
+type reader struct {
+	*bufio.Reader
+}
+
+func (r reader) line() ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		// Lines longer than the read buffer are too big for inline commands or headers
+		return nil, ErrProtocol
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n := len(line); n < 2 || line[n-2] != '\r' {
+		return nil, ErrProtocol
+	}
+	return line[:len(line)-2], nil
+}
+
+func (r reader) int(prefix byte) (int, error) {
+	line, err := r.line()
+	if err != nil {
+		return 0, err
+	}
+	if len(line) < 2 || line[0] != prefix {
+		return 0, ErrProtocol
+	}
+	n, err := strconv.Atoi(string(line[1:]))
+	if err != nil {
+		return 0, ErrProtocol
+	}
+	return n, nil
+}
+
+// bulkChunk is the initial buffer size for reading bulk strings.
+const bulkChunk = 64 << 10
+
+// bulk reads n bytes growing the buffer as data arrives so that a bulk string header alone cannot force a large allocation.
+func (r reader) bulk(n int) ([]byte, error) {
+	buf := make([]byte, 0, min(n, bulkChunk))
+	for len(buf) < n {
+		if len(buf) == cap(buf) {
+			buf = slices.Grow(buf, min(len(buf), n-len(buf)))
+		}
+		m, err := r.Read(buf[len(buf):min(cap(buf), n)])
+		buf = buf[:len(buf)+m]
+		if err != nil && len(buf) < n {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// command reads a client request either as a RESP array of bulk strings
+// or as an inline command.
+func (r reader) command() ([][]byte, error) {
+	b, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if b[0] != '*' {
+		line, err := r.line()
+		if err != nil {
+			return nil, err
+		}
+		fields := bytes.Fields(line)
+		args := make([][]byte, len(fields))
+		for i, f := range fields {
+			args[i] = append([]byte(nil), f...)
+		}
+		return args, nil
+	}
+	n, err := r.int('*')
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > MaxArgs {
+		return nil, ErrProtocol
+	}
+	// Grow args as they arrive so that the count alone cannot force a large allocation
+	args := make([][]byte, 0, min(n, 1024))
+	for i := 0; i < n; i++ {
+		size, err := r.int('$')
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 || size > MaxBulkSize {
+			return nil, ErrProtocol
+		}
+		arg, err := r.bulk(size + 2)
+		if err != nil {
+			return nil, err
+		}
+		if arg[size] != '\r' || arg[size+1] != '\n' {
+			return nil, ErrProtocol
+		}
+		args = append(args, arg[:size])
+	}
+	return args, nil
+}
+
+// writer encodes replies using RESP2 or RESP3 depending on proto.
+type writer struct {
+	*bufio.Writer
+	proto int
+}
+
+func (w *writer) header(prefix byte, n int) {
+	w.WriteByte(prefix)
+	w.WriteString(strconv.Itoa(n))
+	w.WriteString("\r\n")
+}
+
+func (w *writer) simple(s string) {
+	w.WriteByte('+')
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func (w *writer) error(s string) {
+	w.WriteByte('-')
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func (w *writer) int(n int64) {
+	w.WriteByte(':')
+	w.WriteString(strconv.FormatInt(n, 10))
+	w.WriteString("\r\n")
+}
+
+func (w *writer) bulk(b []byte) {
+	w.header('$', len(b))
+	w.Write(b)
+	w.WriteString("\r\n")
+}
+
+func (w *writer) bulkString(s string) {
+	w.header('$', len(s))
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+// verbatim writes a RESP3 verbatim string falling back to a bulk string on RESP2.
+func (w *writer) verbatim(s string) {
+	if w.proto < 3 {
+		w.bulkString(s)
+		return
+	}
+	w.header('=', len(s)+4)
+	w.WriteString("txt:")
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
+
+func (w *writer) null() {
+	if w.proto < 3 {
+		w.WriteString("$-1\r\n")
+		return
+	}
+	w.WriteString("_\r\n")
+}
+
+func (w *writer) array(n int) {
+	w.header('*', n)
+}
+
+// dict writes a RESP3 map header falling back to a flat array on RESP2.
+func (w *writer) dict(n int) {
+	if w.proto < 3 {
+		w.header('*', 2*n)
+		return
+	}
+	w.header('%', n)
+}
@@ -0,0 +1,553 @@
+// Package resp implements a subset of the Redis protocol (RESP2/RESP3) on top of a cache.Interface.
+package resp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+var (
+	// ErrServerClosed is returned by Serve after a call to Close.
+	ErrServerClosed = errors.New("Server closed.")
+)
+
+// Version is the Redis version reported to clients by HELLO and INFO.
+const Version = "7.0.0"
+
+// JanitorInterval is the interval at which expired keys are removed from the cache.
+const JanitorInterval = time.Second
+
+// Server serves Redis clients from an embedded cache.
+// Keys and values are stored as strings and []byte respectively.
+type Server struct {
+	size   int
+	policy cache.EvictionPolicy
+
+	// Protects cache, write commands hold an exclusive lock.
+	mu    sync.RWMutex
+	cache cache.Interface
+	// stop stops the janitor of cache, it is nil after Close
+	stop chan struct{}
+
+	connMu    sync.Mutex
+	closed    bool
+	nextID    int64
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+}
+
+// NewServer returns a Server backed by a cache of the provided size and eviction policy.
+// Size must be positive for caches with an eviction policy.
+func NewServer(size int, policy cache.EvictionPolicy) (*Server, error) {
+	s := &Server{
+		size:      size,
+		policy:    policy,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+		stop:      make(chan struct{}),
+	}
+	c, err := s.build()
+	if err != nil {
+		return nil, err
+	}
+	s.cache = c
+	return s, nil
+}
+
+// build returns a new cache removing expired keys until stop is closed.
+// The caller must hold the lock.
+func (s *Server) build() (cache.Interface, error) {
+	options := []cache.Option{cache.WithCapacity(s.size), cache.WithPolicy(s.policy)}
+	if s.stop != nil {
+		options = append(options, cache.WithJanitor(JanitorInterval, s.stop))
+	}
+	return cache.Build(options...)
+}
+
+// trim removes expired keys so that they are not counted, the caller must hold the lock.
+func (s *Server) trim() {
+	if c, ok := s.cache.(interface {
+		Trim(now time.Time) []interface{}
+	}); ok {
+		c.Trim(time.Now())
+	}
+}
+
+// ListenAndServe listens on a TCP address and serves clients until Close is called.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l until Close is called.
+func (s *Server) Serve(l net.Listener) error {
+	s.connMu.Lock()
+	if s.closed {
+		s.connMu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.connMu.Unlock()
+	defer func() {
+		s.connMu.Lock()
+		delete(s.listeners, l)
+		s.connMu.Unlock()
+	}()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.connMu.Lock()
+			closed := s.closed
+			s.connMu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+		go s.serve(conn)
+	}
+}
+
+// Close closes all listeners and active connections and stops removing expired keys.
+func (s *Server) Close() error {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	s.closed = true
+	s.mu.Lock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	s.mu.Unlock()
+	for l := range s.listeners {
+		l.Close()
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	return nil
+}
+
+func (s *Server) track(conn net.Conn) (id int64, ok bool) {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	if s.closed {
+		return 0, false
+	}
+	s.nextID++
+	s.conns[conn] = struct{}{}
+	return s.nextID, true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.connMu.Lock()
+	delete(s.conns, conn)
+	s.connMu.Unlock()
+	conn.Close()
+}
+
+type client struct {
+	id   int64
+	w    writer
+	quit bool
+}
+
+func (s *Server) serve(conn net.Conn) {
+	id, ok := s.track(conn)
+	if !ok {
+		conn.Close()
+		return
+	}
+	defer s.untrack(conn)
+	r := reader{bufio.NewReader(conn)}
+	c := client{
+		id: id,
+		w:  writer{Writer: bufio.NewWriter(conn), proto: 2},
+	}
+	for !c.quit {
+		args, err := r.command()
+		if err != nil {
+			if err == ErrProtocol {
+				c.w.error("ERR Protocol error")
+				c.w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		s.exec(&c, args)
+		if r.Buffered() == 0 {
+			if err := c.w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+	c.w.Flush()
+}
+
+type command struct {
+	// arity is the exact number of arguments including the command name.
+	// A negative arity means at least -arity arguments.
+	arity int
+	fn    func(s *Server, c *client, args [][]byte)
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"PING":    {-1, (*Server).ping},
+		"ECHO":    {2, (*Server).echo},
+		"QUIT":    {1, (*Server).quit},
+		"HELLO":   {-1, (*Server).hello},
+		"SELECT":  {2, (*Server).selectDB},
+		"COMMAND": {-1, (*Server).command},
+		"CLIENT":  {-2, (*Server).client},
+		"GET":     {2, (*Server).get},
+		"SET":     {-3, (*Server).set},
+		"DEL":     {-2, (*Server).del},
+		"EXISTS":  {-2, (*Server).exists},
+		"TTL":     {2, (*Server).ttl},
+		"PTTL":    {2, (*Server).pttl},
+		"EXPIRE":  {3, (*Server).expire},
+		"MGET":    {-2, (*Server).mget},
+		"MSET":    {-3, (*Server).mset},
+		"INFO":    {-1, (*Server).info},
+		"DBSIZE":  {1, (*Server).dbsize},
+		"FLUSHDB": {-1, (*Server).flushdb},
+	}
+}
+
+func (s *Server) exec(c *client, args [][]byte) {
+	name := strings.ToUpper(string(args[0]))
+	cmd, ok := commands[name]
+	if !ok {
+		c.w.error(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+		return
+	}
+	if n := len(args); (cmd.arity > 0 && n != cmd.arity) || (cmd.arity < 0 && n < -cmd.arity) {
+		c.w.error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)))
+		return
+	}
+	cmd.fn(s, c, args)
+}
+
+const (
+	errSyntax  = "ERR syntax error"
+	errInteger = "ERR value is not an integer or out of range"
+	errOOM     = "OOM command not allowed when used memory > 'maxmemory'."
+)
+
+// lookup returns the value of a fresh key.
+// The caller must hold at least a read lock.
+func (s *Server) lookup(key string) (v []byte, exp time.Time, ok bool) {
+	x, exp, err := s.cache.Get(key)
+	if err != nil {
+		return nil, exp, false
+	}
+	v, ok = x.([]byte)
+	return
+}
+
+func (s *Server) ping(c *client, args [][]byte) {
+	switch len(args) {
+	case 1:
+		c.w.simple("PONG")
+	case 2:
+		c.w.bulk(args[1])
+	default:
+		c.w.error("ERR wrong number of arguments for 'ping' command")
+	}
+}
+
+func (s *Server) echo(c *client, args [][]byte) {
+	c.w.bulk(args[1])
+}
+
+func (s *Server) quit(c *client, args [][]byte) {
+	c.w.simple("OK")
+	c.quit = true
+}
+
+func (s *Server) hello(c *client, args [][]byte) {
+	proto := c.w.proto
+	if len(args) > 1 {
+		n, err := strconv.Atoi(string(args[1]))
+		if err != nil {
+			c.w.error(errInteger)
+			return
+		}
+		if n != 2 && n != 3 {
+			c.w.error("NOPROTO unsupported protocol version")
+			return
+		}
+		proto = n
+	}
+	c.w.proto = proto
+	c.w.dict(7)
+	c.w.bulkString("server")
+	c.w.bulkString("go-cache")
+	c.w.bulkString("version")
+	c.w.bulkString(Version)
+	c.w.bulkString("proto")
+	c.w.int(int64(proto))
+	c.w.bulkString("id")
+	c.w.int(c.id)
+	c.w.bulkString("mode")
+	c.w.bulkString("standalone")
+	c.w.bulkString("role")
+	c.w.bulkString("master")
+	c.w.bulkString("modules")
+	c.w.array(0)
+}
+
+func (s *Server) selectDB(c *client, args [][]byte) {
+	if string(args[1]) != "0" {
+		c.w.error("ERR DB index is out of range")
+		return
+	}
+	c.w.simple("OK")
+}
+
+func (s *Server) command(c *client, args [][]byte) {
+	c.w.array(0)
+}
+
+func (s *Server) client(c *client, args [][]byte) {
+	switch strings.ToUpper(string(args[1])) {
+	case "ID":
+		c.w.int(c.id)
+	default:
+		c.w.simple("OK")
+	}
+}
+
+func (s *Server) get(c *client, args [][]byte) {
+	s.mu.RLock()
+	v, _, ok := s.lookup(string(args[1]))
+	s.mu.RUnlock()
+	if !ok {
+		c.w.null()
+		return
+	}
+	c.w.bulk(v)
+}
+
+func (s *Server) set(c *client, args [][]byte) {
+	var (
+		exp    time.Time
+		nx, xx bool
+	)
+	for i := 3; i < len(args); i++ {
+		switch opt := strings.ToUpper(string(args[i])); opt {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "EX", "PX":
+			if i++; i == len(args) || !exp.IsZero() {
+				c.w.error(errSyntax)
+				return
+			}
+			n, err := strconv.ParseInt(string(args[i]), 10, 64)
+			if err != nil || n <= 0 {
+				c.w.error("ERR invalid expire time in 'set' command")
+				return
+			}
+			unit := time.Second
+			if opt == "PX" {
+				unit = time.Millisecond
+			}
+			exp = time.Now().Add(time.Duration(n) * unit)
+		default:
+			c.w.error(errSyntax)
+			return
+		}
+	}
+	if nx && xx {
+		c.w.error(errSyntax)
+		return
+	}
+	key := string(args[1])
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if nx || xx {
+		if _, _, ok := s.lookup(key); ok != xx {
+			c.w.null()
+			return
+		}
+	}
+	if err := s.cache.Set(key, args[2], exp); err != nil {
+		c.w.error(errOOM)
+		return
+	}
+	c.w.simple("OK")
+}
+
+func (s *Server) del(c *client, args [][]byte) {
+	n := int64(0)
+	keys := make([]interface{}, 0, len(args)-1)
+	s.mu.Lock()
+	for _, arg := range args[1:] {
+		key := string(arg)
+		if _, _, ok := s.lookup(key); ok {
+			n++
+		}
+		keys = append(keys, key)
+	}
+	s.cache.Evict(keys...)
+	s.mu.Unlock()
+	c.w.int(n)
+}
+
+func (s *Server) exists(c *client, args [][]byte) {
+	n := int64(0)
+	s.mu.RLock()
+	for _, arg := range args[1:] {
+		if _, _, ok := s.lookup(string(arg)); ok {
+			n++
+		}
+	}
+	s.mu.RUnlock()
+	c.w.int(n)
+}
+
+func (s *Server) remaining(key string, unit time.Duration) int64 {
+	s.mu.RLock()
+	_, exp, ok := s.lookup(key)
+	s.mu.RUnlock()
+	switch {
+	case !ok:
+		return -2
+	case exp.IsZero():
+		return -1
+	}
+	ttl := time.Until(exp)
+	if ttl < 0 {
+		return 0
+	}
+	return int64((ttl + unit/2) / unit)
+}
+
+func (s *Server) ttl(c *client, args [][]byte) {
+	c.w.int(s.remaining(string(args[1]), time.Second))
+}
+
+func (s *Server) pttl(c *client, args [][]byte) {
+	c.w.int(s.remaining(string(args[1]), time.Millisecond))
+}
+
+func (s *Server) expire(c *client, args [][]byte) {
+	n, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		c.w.error(errInteger)
+		return
+	}
+	key := string(args[1])
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, _, ok := s.lookup(key)
+	if !ok {
+		c.w.int(0)
+		return
+	}
+	if n <= 0 {
+		s.cache.Evict(key)
+	} else if err := s.cache.Set(key, v, time.Now().Add(time.Duration(n)*time.Second)); err != nil {
+		c.w.error(errOOM)
+		return
+	}
+	c.w.int(1)
+}
+
+func (s *Server) mget(c *client, args [][]byte) {
+	c.w.array(len(args) - 1)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, arg := range args[1:] {
+		if v, _, ok := s.lookup(string(arg)); ok {
+			c.w.bulk(v)
+		} else {
+			c.w.null()
+		}
+	}
+}
+
+func (s *Server) mset(c *client, args [][]byte) {
+	if len(args)%2 != 1 {
+		c.w.error("ERR wrong number of arguments for 'mset' command")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 1; i < len(args); i += 2 {
+		if err := s.cache.Set(string(args[i]), args[i+1], cache.Never()); err != nil {
+			c.w.error(errOOM)
+			return
+		}
+	}
+	c.w.simple("OK")
+}
+
+func (s *Server) info(c *client, args [][]byte) {
+	section := "default"
+	if len(args) > 1 {
+		section = strings.ToLower(string(args[1]))
+	}
+	all := section == "default" || section == "all" || section == "everything"
+	s.mu.Lock()
+	s.trim()
+	m := s.cache.Metrics()
+	s.mu.Unlock()
+	b := new(strings.Builder)
+	if all || section == "server" {
+		fmt.Fprintf(b, "# Server\r\nredis_version:%s\r\nredis_mode:standalone\r\n", Version)
+		fmt.Fprintf(b, "cache_policy:%s\r\ncache_size:%d\r\n\r\n", s.policy, s.size)
+	}
+	if all || section == "stats" {
+		fmt.Fprintf(b, "# Stats\r\nkeyspace_hits:%d\r\nkeyspace_misses:%d\r\n", m.Hit, m.Miss)
+		fmt.Fprintf(b, "evicted_keys:%d\r\nexpired_keys:%d\r\n\r\n", m.Evict, m.Expired)
+	}
+	if (all || section == "keyspace") && m.Items > 0 {
+		fmt.Fprintf(b, "# Keyspace\r\ndb0:keys=%d,expires=0,avg_ttl=0\r\n", m.Items)
+	}
+	c.w.verbatim(b.String())
+}
+
+func (s *Server) dbsize(c *client, args [][]byte) {
+	s.mu.Lock()
+	s.trim()
+	n := s.cache.Evict()
+	s.mu.Unlock()
+	c.w.int(int64(n))
+}
+
+func (s *Server) flushdb(c *client, args [][]byte) {
+	if len(args) > 2 {
+		c.w.error(errSyntax)
+		return
+	}
+	s.mu.Lock()
+	if s.stop != nil {
+		// Stop the janitor of the old cache
+		close(s.stop)
+		s.stop = make(chan struct{})
+	}
+	// Options were validated by NewServer
+	s.cache, _ = s.build()
+	s.mu.Unlock()
+	c.w.simple("OK")
+}
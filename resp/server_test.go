@@ -0,0 +1,229 @@
+package resp_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+	"github.com/alxarch/go-cache/resp"
+)
+
+type client struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (c *client) do(t *testing.T, args ...string) string {
+	t.Helper()
+	fmt.Fprintf(c.conn, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.conn, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	reply, err := c.reply()
+	if err != nil {
+		t.Fatalf("%s: %s", args[0], err)
+	}
+	return reply
+}
+
+// reply reads a reply and returns it in a compact textual form.
+func (c *client) reply() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	switch line[0] {
+	case '+', '-', ':', '_':
+		return line, nil
+	case '$', '=':
+		n, _ := strconv.Atoi(line[1:])
+		if n < 0 {
+			return "$-1", nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, b); err != nil {
+			return "", err
+		}
+		return string(b[:n]), nil
+	case '*', '%':
+		n, _ := strconv.Atoi(line[1:])
+		if line[0] == '%' {
+			n *= 2
+		}
+		items := make([]string, n)
+		for i := range items {
+			if items[i], err = c.reply(); err != nil {
+				return "", err
+			}
+		}
+		return "[" + strings.Join(items, " ") + "]", nil
+	}
+	return "", fmt.Errorf("invalid reply %q", line)
+}
+
+func Test_Server(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resp.NewServer(0, cache.PolicyLRU); err != cache.ErrInvalidCapacity {
+		t.Errorf("Invalid error %v", err)
+	}
+	s, err := resp.NewServer(100, cache.PolicyLRU)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() {
+		done <- s.Serve(l)
+	}()
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &client{conn, bufio.NewReader(conn)}
+	for _, tc := range []struct {
+		args  []string
+		reply string
+	}{
+		{[]string{"PING"}, "+PONG"},
+		{[]string{"GET", "foo"}, "$-1"},
+		{[]string{"SET", "foo", "bar"}, "+OK"},
+		{[]string{"GET", "foo"}, "bar"},
+		{[]string{"SET", "foo", "baz", "NX"}, "$-1"},
+		{[]string{"SET", "bar", "baz", "XX"}, "$-1"},
+		{[]string{"SET", "foo", "baz", "XX", "EX", "100"}, "+OK"},
+		{[]string{"TTL", "foo"}, ":100"},
+		{[]string{"PTTL", "foo"}, ":100000"},
+		{[]string{"TTL", "bar"}, ":-2"},
+		{[]string{"SET", "bar", "baz", "PX", "foo"}, "-ERR invalid expire time in 'set' command"},
+		{[]string{"MSET", "bar", "1", "baz", "2"}, "+OK"},
+		{[]string{"TTL", "bar"}, ":-1"},
+		{[]string{"EXPIRE", "bar", "10"}, ":1"},
+		{[]string{"TTL", "bar"}, ":10"},
+		{[]string{"EXPIRE", "qux", "10"}, ":0"},
+		{[]string{"MGET", "foo", "bar", "qux"}, "[baz 1 $-1]"},
+		{[]string{"EXISTS", "foo", "bar", "qux", "foo"}, ":3"},
+		{[]string{"DBSIZE"}, ":3"},
+		{[]string{"DEL", "foo", "qux"}, ":1"},
+		{[]string{"DBSIZE"}, ":2"},
+		{[]string{"GET"}, "-ERR wrong number of arguments for 'get' command"},
+		{[]string{"FOO"}, "-ERR unknown command 'FOO'"},
+		{[]string{"FLUSHDB"}, "+OK"},
+		{[]string{"DBSIZE"}, ":0"},
+		{[]string{"HELLO", "3"}, "[server go-cache version 7.0.0 proto :3 id :1 mode standalone role master modules []]"},
+		{[]string{"GET", "foo"}, "_"},
+	} {
+		if reply := c.do(t, tc.args...); reply != tc.reply {
+			t.Errorf("%v: invalid reply %q != %q", tc.args, reply, tc.reply)
+		}
+	}
+	if info := c.do(t, "INFO", "stats"); !strings.HasPrefix(info, "txt:# Stats\r\nkeyspace_hits:0\r\nkeyspace_misses:1\r\n") {
+		t.Errorf("Invalid info %q", info)
+	}
+	fmt.Fprintf(conn, "PING\r\n")
+	if reply, err := c.reply(); err != nil || reply != "+PONG" {
+		t.Errorf("Invalid inline reply %q %v", reply, err)
+	}
+	if reply := c.do(t, "QUIT"); reply != "+OK" {
+		t.Errorf("Invalid reply %q", reply)
+	}
+	s.Close()
+	if err := <-done; err != resp.ErrServerClosed {
+		t.Errorf("Invalid serve error %s", err)
+	}
+}
+
+func Test_ServerProtocolLimits(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := resp.NewServer(100, cache.PolicyLRU)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	go s.Serve(l)
+	for _, req := range []string{
+		fmt.Sprintf("*%d\r\n", resp.MaxArgs+1),
+		"*2000000000\r\n",
+		// Inline requests longer than the read buffer
+		strings.Repeat("a", 4096),
+	} {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := &client{conn, bufio.NewReader(conn)}
+		io.WriteString(conn, req)
+		if reply, err := c.reply(); err != nil || reply != "-ERR Protocol error" {
+			t.Errorf("Invalid reply %q %v", reply, err)
+		}
+		conn.Close()
+	}
+}
+
+func Test_ServerExpired(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := resp.NewServer(0, cache.PolicyNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	go s.Serve(l)
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c := &client{conn, bufio.NewReader(conn)}
+	c.do(t, "SET", "foo", "bar", "PX", "1")
+	c.do(t, "SET", "bar", "baz")
+	time.Sleep(10 * time.Millisecond)
+	if reply := c.do(t, "DBSIZE"); reply != ":1" {
+		t.Errorf("Invalid reply %q", reply)
+	}
+	if info := c.do(t, "INFO", "keyspace"); !strings.Contains(info, "db0:keys=1,") {
+		t.Errorf("Invalid info %q", info)
+	}
+}
+
+func Test_ServerBulkHeader(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := resp.NewServer(100, cache.PolicyLRU)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	go s.Serve(l)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(conn, "*1\r\n$%d\r\nfoo", resp.MaxBulkSize)
+	conn.(*net.TCPConn).CloseWrite()
+	// The server closes the connection once the request is cut short
+	io.Copy(io.Discard, conn)
+	conn.Close()
+	runtime.ReadMemStats(&after)
+	if n := after.TotalAlloc - before.TotalAlloc; n > 16<<20 {
+		t.Errorf("Allocated %d bytes for a bulk string header", n)
+	}
+}
@@ -0,0 +1,280 @@
+package cache
+
+import (
+	"errors"
+	"hash/maphash"
+	"iter"
+	"time"
+)
+
+// Sharded implements Interface by splitting keys among independent caches to reduce lock contention.
+type Sharded struct {
+	shards []Interface
+	seed   maphash.Seed
+}
+
+// NewSharded returns a Sharded cache distributing keys among shards.
+func NewSharded(shards ...Interface) *Sharded {
+	return &Sharded{
+		shards: shards,
+		seed:   maphash.MakeSeed(),
+	}
+}
+
+// Shard returns the shard that holds a key.
+func (s *Sharded) Shard(k interface{}) Interface {
+	return s.shards[maphash.Comparable(s.seed, k)%uint64(len(s.shards))]
+}
+
+func (s *Sharded) Get(k interface{}) (interface{}, time.Time, error) {
+	return s.Shard(k).Get(k)
+}
+
+func (s *Sharded) Set(k, v interface{}, exp time.Time) error {
+	return s.Shard(k).Set(k, v, exp)
+}
+
+// Evict removes items from all shards and returns the total size.
+func (s *Sharded) Evict(keys ...interface{}) (size int) {
+	byShard := make(map[Interface][]interface{}, len(s.shards))
+	for _, k := range keys {
+		shard := s.Shard(k)
+		byShard[shard] = append(byShard[shard], k)
+	}
+	for _, shard := range s.shards {
+		size += shard.Evict(byShard[shard]...)
+	}
+	return
+}
+
+// Trim removes expired items from all shards that support it.
+func (s *Sharded) Trim(now time.Time) (expired []interface{}) {
+	for _, shard := range s.shards {
+		if t, ok := shard.(trimmer); ok {
+			expired = append(expired, t.Trim(now)...)
+		}
+	}
+	return
+}
+
+// All returns an iterator over the items of all shards that support iteration.
+func (s *Sharded) All() iter.Seq2[interface{}, Item] {
+	return func(yield func(interface{}, Item) bool) {
+		for _, shard := range s.shards {
+			r, ok := shard.(ranger)
+			if !ok {
+				continue
+			}
+			for k, item := range r.All() {
+				if !yield(k, item) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Keys returns the keys of all shards that support iteration.
+func (s *Sharded) Keys() (keys []interface{}) {
+	for k := range s.All() {
+		keys = append(keys, k)
+	}
+	return
+}
+
+// SetTagged sets a key with tags on its shard, see Cache.SetTagged.
+// Shards that do not support tags set the key without them.
+func (s *Sharded) SetTagged(k, v interface{}, exp time.Time, tags ...interface{}) error {
+	shard := s.Shard(k)
+	if t, ok := shard.(tagger); ok {
+		return t.SetTagged(k, v, exp, tags...)
+	}
+	return shard.Set(k, v, exp)
+}
+
+// EvictTag removes items with any of the tags from all shards and returns the number of removed items.
+func (s *Sharded) EvictTag(tags ...interface{}) (n int) {
+	for _, shard := range s.shards {
+		if t, ok := shard.(tagger); ok {
+			n += t.EvictTag(tags...)
+		}
+	}
+	return
+}
+
+// EvictPrefix removes items with string keys starting with prefix from all shards and returns the number of removed items.
+func (s *Sharded) EvictPrefix(prefix string) (n int) {
+	for _, shard := range s.shards {
+		if t, ok := shard.(tagger); ok {
+			n += t.EvictPrefix(prefix)
+		}
+	}
+	return
+}
+
+// Purge removes all items from shards that support it and returns the number of removed items.
+func (s *Sharded) Purge() (n int) {
+	for _, shard := range s.shards {
+		if p, ok := shard.(interface{ Purge() int }); ok {
+			n += p.Purge()
+		}
+	}
+	return
+}
+
+// Clock returns the clock of the first shard.
+func (s *Sharded) Clock() Clock {
+	return clockOf(s.shards[0])
+}
+
+// Metrics returns the sum of metrics of all shards.
+func (s *Sharded) Metrics() (m Metrics) {
+	for _, shard := range s.shards {
+		m = m.add(shard.Metrics())
+	}
+	return
+}
+
+type computer interface {
+	Compute(k interface{}, fn ComputeFunc) (interface{}, error)
+	GetOrSet(k, v interface{}, exp time.Time) (interface{}, bool, error)
+	SetIfAbsent(k, v interface{}, exp time.Time) (bool, error)
+	Replace(k, v interface{}, exp time.Time) (bool, error)
+	CompareAndSwap(k, old, v interface{}, exp time.Time) (bool, error)
+	GetAndDelete(k interface{}) (interface{}, bool)
+}
+
+// Compute atomically replaces the value of a key on its shard, see Cache.Compute.
+func (s *Sharded) Compute(k interface{}, fn ComputeFunc) (interface{}, error) {
+	if c, ok := s.Shard(k).(computer); ok {
+		return c.Compute(k, fn)
+	}
+	return nil, errors.ErrUnsupported
+}
+
+// GetOrSet returns the value of a key or sets it on its shard, see Cache.GetOrSet.
+func (s *Sharded) GetOrSet(k, v interface{}, exp time.Time) (interface{}, bool, error) {
+	if c, ok := s.Shard(k).(computer); ok {
+		return c.GetOrSet(k, v, exp)
+	}
+	return nil, false, errors.ErrUnsupported
+}
+
+// SetIfAbsent sets a key on its shard if it is missing, see Cache.SetIfAbsent.
+func (s *Sharded) SetIfAbsent(k, v interface{}, exp time.Time) (bool, error) {
+	if c, ok := s.Shard(k).(computer); ok {
+		return c.SetIfAbsent(k, v, exp)
+	}
+	return false, errors.ErrUnsupported
+}
+
+// Replace sets a key on its shard if it exists, see Cache.Replace.
+func (s *Sharded) Replace(k, v interface{}, exp time.Time) (bool, error) {
+	if c, ok := s.Shard(k).(computer); ok {
+		return c.Replace(k, v, exp)
+	}
+	return false, errors.ErrUnsupported
+}
+
+// CompareAndSwap swaps the value of a key on its shard, see Cache.CompareAndSwap.
+func (s *Sharded) CompareAndSwap(k, old, v interface{}, exp time.Time) (bool, error) {
+	if c, ok := s.Shard(k).(computer); ok {
+		return c.CompareAndSwap(k, old, v, exp)
+	}
+	return false, errors.ErrUnsupported
+}
+
+// GetAndDelete removes a key from its shard and returns its value, see Cache.GetAndDelete.
+func (s *Sharded) GetAndDelete(k interface{}) (interface{}, bool) {
+	if c, ok := s.Shard(k).(computer); ok {
+		return c.GetAndDelete(k)
+	}
+	return nil, false
+}
+
+type counter interface {
+	Incr(k interface{}, delta int64, ttl time.Duration) (int64, error)
+	IncrFloat(k interface{}, delta float64, ttl time.Duration) (float64, error)
+}
+
+// Peek returns a key from its shard without side effects, see Cache.Peek.
+
+// Incr adds delta to a counter on its shard, see Cache.Incr.
+func (s *Sharded) Incr(k interface{}, delta int64, ttl time.Duration) (int64, error) {
+	if c, ok := s.Shard(k).(counter); ok {
+		return c.Incr(k, delta, ttl)
+	}
+	return 0, errors.ErrUnsupported
+}
+
+// Decr subtracts delta from a counter on its shard, see Cache.Incr.
+func (s *Sharded) Decr(k interface{}, delta int64, ttl time.Duration) (int64, error) {
+	return s.Incr(k, -delta, ttl)
+}
+
+// IncrFloat adds delta to a float counter on its shard, see Cache.IncrFloat.
+func (s *Sharded) IncrFloat(k interface{}, delta float64, ttl time.Duration) (float64, error) {
+	if c, ok := s.Shard(k).(counter); ok {
+		return c.IncrFloat(k, delta, ttl)
+	}
+	return 0, errors.ErrUnsupported
+}
+
+// DecrFloat subtracts delta from a float counter on its shard, see Cache.IncrFloat.
+func (s *Sharded) DecrFloat(k interface{}, delta float64, ttl time.Duration) (float64, error) {
+	return s.IncrFloat(k, -delta, ttl)
+}
+
+type peeker interface {
+	Peek(k interface{}) (interface{}, time.Time, error)
+	TTL(k interface{}) (time.Duration, error)
+	Touch(k interface{}, exp time.Time) bool
+}
+
+// Peek returns a key from its shard without side effects, see Cache.Peek.
+// Shards that do not support it return errors.ErrUnsupported.
+func (s *Sharded) Peek(k interface{}) (interface{}, time.Time, error) {
+	if p, ok := s.Shard(k).(peeker); ok {
+		return p.Peek(k)
+	}
+	return nil, time.Time{}, errors.ErrUnsupported
+}
+
+// TTL returns the remaining time to live of a key from its shard, see Cache.TTL.
+func (s *Sharded) TTL(k interface{}) (time.Duration, error) {
+	if p, ok := s.Shard(k).(peeker); ok {
+		return p.TTL(k)
+	}
+	return 0, errors.ErrUnsupported
+}
+
+// Touch sets the expiration time of a key on its shard, see Cache.Touch.
+func (s *Sharded) Touch(k interface{}, exp time.Time) bool {
+	if p, ok := s.Shard(k).(peeker); ok {
+		return p.Touch(k, exp)
+	}
+	return false
+}
+
+// Resize splits a new capacity among shards like WithShards, see Cache.Resize.
+// It returns ErrInvalidShards if the capacity is positive but less than the number of shards.
+// It returns the first error of a shard, shards resized before it keep their new capacity.
+func (s *Sharded) Resize(size int) error {
+	if size > 0 && size < len(s.shards) {
+		return ErrInvalidShards
+	}
+	for i, shard := range s.shards {
+		r, ok := shard.(interface{ Resize(size int) error })
+		if !ok {
+			return errors.ErrUnsupported
+		}
+		n := size
+		if n > 0 {
+			n = shardSize(size, len(s.shards), i)
+		}
+		if err := r.Resize(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package cache_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+func Test_Sharded(t *testing.T) {
+	c, err := cache.Build(cache.WithShards(4), cache.WithCapacity(100), cache.WithPolicy(cache.PolicyLRU))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, ok := c.(*cache.Sharded)
+	if !ok {
+		t.Fatalf("Invalid type %T", c)
+	}
+	for i := 0; i < 50; i++ {
+		s.Set(i, fmt.Sprint(i), cache.Never())
+	}
+	s.Set("expired", true, time.Now().Add(-time.Second))
+	for i := 0; i < 50; i++ {
+		if v, _, err := s.Get(i); err != nil || v != fmt.Sprint(i) {
+			t.Errorf("Invalid value %v %v", v, err)
+		}
+	}
+	if n := s.Evict(0, 1, 2); n != 48 {
+		t.Errorf("Invalid size %d", n)
+	}
+	if expired := s.Trim(time.Now()); len(expired) != 1 || expired[0] != "expired" {
+		t.Errorf("Invalid trim %v", expired)
+	}
+	if keys := s.Keys(); len(keys) != 47 {
+		t.Errorf("Invalid keys %v", keys)
+	}
+	if m := s.Metrics(); m.Hit != 50 || m.EvictExplicit != 3 || m.Expired != 1 || m.Items != 47 {
+		t.Errorf("Invalid metrics %#v", m)
+	}
+	if n := s.Purge(); n != 47 || s.Evict() != 0 {
+		t.Errorf("Invalid purge %d", n)
+	}
+}
+
+func Test_ShardedCompute(t *testing.T) {
+	c, err := cache.Build(cache.WithShards(4), cache.WithCapacity(100), cache.WithPolicy(cache.PolicyTTL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := c.(*cache.Sharded)
+	for i := 0; i < 10; i++ {
+		if ok, err := s.SetIfAbsent(i, 1, time.Time{}); !ok || err != nil {
+			t.Errorf("Invalid SetIfAbsent %v %v", ok, err)
+		}
+		if ok, err := s.CompareAndSwap(i, 1, "foo", time.Time{}); !ok || err != nil {
+			t.Errorf("Invalid CompareAndSwap %v %v", ok, err)
+		}
+	}
+	if v, ok := s.GetAndDelete(0); !ok || v != "foo" {
+		t.Errorf("Invalid GetAndDelete %v %v", v, ok)
+	}
+	custom := cache.NewSharded(struct{ cache.Interface }{cache.New(10)})
+	if _, err := custom.Compute("foo", func(interface{}, bool) (interface{}, time.Time, bool) {
+		return 1, time.Time{}, true
+	}); err != errors.ErrUnsupported {
+		t.Errorf("Invalid error %v", err)
+	}
+}
+
+func Test_ShardedCounters(t *testing.T) {
+	c, err := cache.Build(cache.WithShards(4), cache.WithCapacity(100), cache.WithPolicy(cache.PolicyTTL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := c.(*cache.Sharded)
+	// Sharded caches work as rate limit stores
+	var _ interface {
+		Incr(k interface{}, delta int64, ttl time.Duration) (int64, error)
+		Clock() cache.Clock
+	} = s
+	for i := 0; i < 10; i++ {
+		if n, err := s.Incr(i, 2, time.Minute); err != nil || n != 2 {
+			t.Errorf("Invalid Incr %d %v", n, err)
+		}
+		if n, err := s.Decr(i, 1, time.Minute); err != nil || n != 1 {
+			t.Errorf("Invalid Decr %d %v", n, err)
+		}
+	}
+	custom := cache.NewSharded(struct{ cache.Interface }{cache.New(10)})
+	if _, err := custom.Incr("foo", 1, 0); err != errors.ErrUnsupported {
+		t.Errorf("Invalid error %v", err)
+	}
+}
+
+func Test_ShardedPeek(t *testing.T) {
+	c, err := cache.Build(cache.WithShards(4), cache.WithCapacity(100), cache.WithPolicy(cache.PolicyTTL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := c.(*cache.Sharded)
+	for i := 0; i < 10; i++ {
+		s.Set(i, i, time.Now().Add(time.Minute))
+		if v, _, err := s.Peek(i); err != nil || v != i {
+			t.Errorf("Invalid Peek %v %v", v, err)
+		}
+		if d, err := s.TTL(i); err != nil || d <= 0 || d > time.Minute {
+			t.Errorf("Invalid TTL %s %v", d, err)
+		}
+		if !s.Touch(i, cache.Never()) {
+			t.Errorf("Touch failed %d", i)
+		}
+		if d, err := s.TTL(i); err != nil || d >= 0 {
+			t.Errorf("Invalid TTL %s %v", d, err)
+		}
+	}
+	custom := cache.NewSharded(struct{ cache.Interface }{cache.New(10)})
+	if _, _, err := custom.Peek("foo"); err != errors.ErrUnsupported {
+		t.Errorf("Invalid error %v", err)
+	}
+}
+
+func Test_ShardedResize(t *testing.T) {
+	c, err := cache.Build(cache.WithShards(4), cache.WithCapacity(100), cache.WithPolicy(cache.PolicyLRU))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := c.(*cache.Sharded)
+	for i := 0; i < 50; i++ {
+		s.Set(i, i, time.Time{})
+	}
+	if err := s.Resize(10); err != nil {
+		t.Errorf("Invalid Resize %v", err)
+	}
+	if n := s.Evict(); n != 10 {
+		t.Errorf("Invalid size %d", n)
+	}
+	if err := s.Resize(3); err != cache.ErrInvalidShards {
+		t.Errorf("Invalid error %v", err)
+	}
+	custom := cache.NewSharded(struct{ cache.Interface }{cache.New(10)})
+	if err := custom.Resize(8); err != errors.ErrUnsupported {
+		t.Errorf("Invalid error %v", err)
+	}
+}
+
+func Test_ShardedCapacity(t *testing.T) {
+	c, err := cache.Build(cache.WithShards(4), cache.WithCapacity(10), cache.WithPolicy(cache.PolicyLRU))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		c.Set(i, i, time.Time{})
+	}
+	if n := c.Evict(); n != 10 {
+		t.Errorf("Invalid size %d", n)
+	}
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// tagger is implemented by caches that support tags.
+type tagger interface {
+	SetTagged(k, v interface{}, exp time.Time, tags ...interface{}) error
+	EvictTag(tags ...interface{}) int
+	EvictPrefix(prefix string) int
+}
+
+// SetTagged assigns a value to a key like Set and attaches tags to it for use with EvictTag.
+// Setting the key again replaces its tags.
+func (c *Cache) SetTagged(k, v interface{}, exp time.Time, tags ...interface{}) (err error) {
+	exp = c.expires(exp)
+	if p := c.policy; p != nil {
+		p.lockPolicy()
+		if err = p.setLocked(k, v, exp); err == nil {
+			c.mu.Lock()
+			c.tag(k, tags)
+			c.mu.Unlock()
+		}
+		p.unlockPolicy()
+		return
+	}
+	w := c.weigh(k, v)
+	c.mu.Lock()
+	if err = c.insert(k, v, exp, w); err == nil {
+		c.tag(k, tags)
+	}
+	c.mu.Unlock()
+	return
+}
+
+// tag attaches tags to a key, the caller must hold the lock.
+func (c *Cache) tag(k interface{}, tags []interface{}) {
+	e := c.values[k]
+	if e == nil || len(tags) == 0 {
+		return
+	}
+	if c.tags == nil {
+		c.tags = make(map[interface{}]map[interface{}]struct{})
+	}
+	for _, t := range tags {
+		keys := c.tags[t]
+		if keys == nil {
+			keys = make(map[interface{}]struct{})
+			c.tags[t] = keys
+		}
+		keys[k] = struct{}{}
+	}
+	e.tags = append(e.tags, tags...)
+}
+
+// untag detaches tags from a key, the caller must hold the lock.
+func (c *Cache) untag(k interface{}, tags []interface{}) {
+	for _, t := range tags {
+		if keys := c.tags[t]; keys != nil {
+			delete(keys, k)
+			if len(keys) == 0 {
+				delete(c.tags, t)
+			}
+		}
+	}
+}
+
+// EvictTag atomically removes all items with any of the tags and returns the number of removed items.
+func (c *Cache) EvictTag(tags ...interface{}) int {
+	return c.evictMatching(func() (keys []interface{}) {
+		seen := make(map[interface{}]struct{})
+		for _, t := range tags {
+			for k := range c.tags[t] {
+				if _, ok := seen[k]; !ok {
+					seen[k] = struct{}{}
+					keys = append(keys, k)
+				}
+			}
+		}
+		return
+	})
+}
+
+// EvictPrefix atomically removes all items with string keys starting with prefix and returns the number of removed items.
+func (c *Cache) EvictPrefix(prefix string) int {
+	return c.evictMatching(func() (keys []interface{}) {
+		for k := range c.values {
+			if s, ok := k.(string); ok && strings.HasPrefix(s, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		return
+	})
+}
+
+// evictMatching removes the keys returned by match which is called with the lock held.
+func (c *Cache) evictMatching(match func() []interface{}) int {
+	if p := c.policy; p != nil {
+		p.lockPolicy()
+		defer p.unlockPolicy()
+		c.mu.RLock()
+		keys := match()
+		c.mu.RUnlock()
+		if len(keys) > 0 {
+			p.evictLocked(keys)
+		}
+		return len(keys)
+	}
+	c.mu.Lock()
+	n := c.evict(match(), ReasonExplicit)
+	atomic.AddUint64(&c.metrics.EvictExplicit, uint64(n))
+	c.unlock()
+	return n
+}
@@ -0,0 +1,66 @@
+package cache_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+)
+
+type tagger interface {
+	cache.Interface
+	SetTagged(k, v interface{}, exp time.Time, tags ...interface{}) error
+	EvictTag(tags ...interface{}) int
+	EvictPrefix(prefix string) int
+}
+
+func Test_EvictTag(t *testing.T) {
+	for _, policy := range policies {
+		c, err := cache.Build(cache.WithCapacity(3), cache.WithPolicy(policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tc := c.(tagger)
+		tc.SetTagged("user:1:profile", 1, time.Time{}, "user:1")
+		tc.SetTagged("user:1:posts", 2, time.Time{}, "user:1", "posts")
+		tc.SetTagged("user:2:posts", 3, time.Time{}, "user:2", "posts")
+		if n := tc.EvictTag("user:1"); n != 2 {
+			t.Errorf("%s: Invalid EvictTag %d", policy, n)
+		}
+		if n := tc.EvictTag("user:1"); n != 0 {
+			t.Errorf("%s: Invalid EvictTag %d", policy, n)
+		}
+		// Overwriting a key drops its tags
+		tc.Set("user:2:posts", 4, time.Time{})
+		if n := tc.EvictTag("posts"); n != 0 {
+			t.Errorf("%s: Invalid EvictTag %d", policy, n)
+		}
+		tc.SetTagged("foo", 1, time.Time{}, "posts")
+		if n := tc.EvictPrefix("user:"); n != 1 || tc.Evict() != 1 {
+			t.Errorf("%s: Invalid EvictPrefix %d", policy, n)
+		}
+		if m := tc.Metrics(); m.EvictExplicit != 3 {
+			t.Errorf("%s: Invalid metrics %#v", policy, m)
+		}
+	}
+}
+
+func Test_TagCleanup(t *testing.T) {
+	c := cache.NewLRU(2)
+	for i := 0; i < 4; i++ {
+		c.SetTagged(i, i, time.Time{}, "all", fmt.Sprint(i))
+	}
+	// Evicted keys are no longer tagged
+	if n := c.EvictTag("0", "1", "2"); n != 1 {
+		t.Errorf("Invalid EvictTag %d", n)
+	}
+	c.SetTagged("expired", true, c.Exp(-time.Second), "all")
+	c.Trim(time.Now())
+	if n := c.EvictTag("all"); n != 1 {
+		t.Errorf("Invalid EvictTag %d", n)
+	}
+	if n := c.Evict(); n != 0 {
+		t.Errorf("Invalid size %d", n)
+	}
+}
@@ -0,0 +1,46 @@
+package cache
+
+// Tracer starts spans for traced cache operations.
+// Implementations can adapt it to OpenTelemetry or any other tracing system.
+type Tracer interface {
+	// Start starts a new span, parent is nil for root spans.
+	Start(parent Span, name string) Span
+}
+
+// Span is a traced operation.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	End()
+}
+
+// Span names and attributes used by Proxy and Blocking.
+const (
+	// SpanGet is the root span of a Proxy lookup.
+	SpanGet = "cache.get"
+	// SpanLookup covers the cache lookup of a Proxy.
+	SpanLookup = "cache.lookup"
+	// SpanLoad covers waiting for a value from Blocking, either loaded or shared.
+	SpanLoad = "cache.load"
+	// SpanFetch covers the upstream request of a Blocking load.
+	SpanFetch = "cache.fetch"
+
+	// AttrResult is one of "hit", "miss", "expired", "refresh" for hits reloaded early,
+	// "stale" for values served after a failed refresh
+	// or "rejected" for misses answered by the Filter of a Proxy.
+	AttrResult = "cache.result"
+	// AttrShared is true if a load waited for a request already in flight.
+	AttrShared = "cache.shared"
+	// AttrError is the error message of a failed operation.
+	AttrError = "error"
+)
+
+func result(err error) string {
+	switch err {
+	case nil:
+		return "hit"
+	case ErrExpired:
+		return "expired"
+	default:
+		return "miss"
+	}
+}
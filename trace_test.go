@@ -0,0 +1,180 @@
+package cache_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	cache "github.com/alxarch/go-cache"
+	"github.com/alxarch/go-cache/cachetest"
+)
+
+type span struct {
+	tracer *tracer
+	name   string
+	parent *span
+	attrs  map[string]interface{}
+	ended  bool
+}
+
+func (s *span) SetAttribute(key string, value interface{}) {
+	s.tracer.mu.Lock()
+	s.attrs[key] = value
+	s.tracer.mu.Unlock()
+}
+
+func (s *span) End() {
+	s.tracer.mu.Lock()
+	s.ended = true
+	s.tracer.mu.Unlock()
+}
+
+type tracer struct {
+	mu    sync.Mutex
+	spans []*span
+}
+
+func (t *tracer) Start(parent cache.Span, name string) cache.Span {
+	s := &span{tracer: t, name: name, attrs: make(map[string]interface{})}
+	if parent != nil {
+		s.parent = parent.(*span)
+	}
+	t.mu.Lock()
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+	return s
+}
+
+func (t *tracer) reset() []*span {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spans := t.spans
+	t.spans = nil
+	for _, s := range spans {
+		if !s.ended {
+			panic("span not ended " + s.name)
+		}
+	}
+	return spans
+}
+
+func Test_Trace(t *testing.T) {
+	tr := new(tracer)
+	c := cache.New(0)
+	p := cache.Proxy(cache.UpstreamFunc(func(x interface{}) (interface{}, time.Time, error) {
+		if x == "missing" {
+			return nil, time.Time{}, cache.ErrKeyNotFound
+		}
+		return x, cache.Never(), nil
+	}), c, cache.WithTracer(tr))
+
+	p.Get("foo")
+	spans := tr.reset()
+	if len(spans) != 4 {
+		t.Fatalf("Invalid spans %d", len(spans))
+	}
+	get, lookup, load, fetch := spans[0], spans[1], spans[2], spans[3]
+	if get.name != cache.SpanGet || get.parent != nil || get.attrs[cache.AttrResult] != "miss" {
+		t.Errorf("Invalid span %#v", get)
+	}
+	if lookup.name != cache.SpanLookup || lookup.parent != get || lookup.attrs[cache.AttrResult] != "miss" {
+		t.Errorf("Invalid span %#v", lookup)
+	}
+	if load.name != cache.SpanLoad || load.parent != get || load.attrs[cache.AttrShared] != false {
+		t.Errorf("Invalid span %#v", load)
+	}
+	if fetch.name != cache.SpanFetch || fetch.parent != load {
+		t.Errorf("Invalid span %#v", fetch)
+	}
+
+	p.Get("foo")
+	if spans := tr.reset(); len(spans) != 2 || spans[0].attrs[cache.AttrResult] != "hit" {
+		t.Errorf("Invalid spans %v", spans)
+	}
+
+	c.Set("bar", "baz", time.Now().Add(-time.Second))
+	p.Get("bar")
+	if spans := tr.reset(); len(spans) != 4 || spans[1].attrs[cache.AttrResult] != "expired" {
+		t.Errorf("Invalid spans %v", spans)
+	}
+
+	p.Get("missing")
+	if spans := tr.reset(); len(spans) != 4 || spans[0].attrs[cache.AttrError] != cache.ErrKeyNotFound.Error() {
+		t.Errorf("Invalid spans %v", spans)
+	}
+
+	release := make(chan struct{})
+	b := cache.TraceBlocking(cache.UpstreamFunc(func(x interface{}) (interface{}, time.Time, error) {
+		<-release
+		return x, cache.Never(), nil
+	}), tr)
+	done := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		go func() {
+			b.Get("foo")
+			done <- struct{}{}
+		}()
+	}
+	for b.(interface{ Metrics() cache.Metrics }).Metrics().Shared == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	<-done
+	<-done
+	shared := 0
+	for _, s := range tr.reset() {
+		if s.name == cache.SpanLoad && s.attrs[cache.AttrShared] == true {
+			shared++
+		}
+	}
+	if shared != 1 {
+		t.Errorf("Invalid shared spans %d", shared)
+	}
+}
+
+func Test_TraceStale(t *testing.T) {
+	tr := new(tracer)
+	clock := cachetest.NewClock(time.Now())
+	c, err := cache.Build(cache.WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := false
+	p := cache.Proxy(cache.UpstreamFunc(func(x interface{}) (interface{}, time.Time, error) {
+		if loaded {
+			return nil, time.Time{}, errors.New("Unavailable")
+		}
+		loaded = true
+		clock.Advance(time.Second)
+		return x, clock.Now().Add(time.Hour), nil
+	}), c, cache.WithTracer(tr), cache.WithEarlyRefresh(1))
+	p.Get("foo")
+	clock.Advance(time.Hour - time.Millisecond)
+	tr.reset()
+	for i := 0; i < 20; i++ {
+		if v, _, err := p.Get("foo"); err != nil || v != "foo" {
+			t.Fatalf("Invalid Get %v %v", v, err)
+		}
+		if spans := tr.reset(); spans[0].attrs[cache.AttrResult] == "stale" {
+			if spans[0].attrs[cache.AttrError] != "Unavailable" {
+				t.Errorf("Invalid span %#v", spans[0])
+			}
+			return
+		}
+	}
+	t.Error("No stale result")
+}
+
+func Test_ProxyHitAllocs(t *testing.T) {
+	c := cache.New(0)
+	p := cache.Proxy(cache.UpstreamFunc(func(x interface{}) (interface{}, time.Time, error) {
+		return x, cache.Never(), nil
+	}), c)
+	p.Get("foo")
+	if n := testing.AllocsPerRun(100, func() {
+		p.Get("foo")
+	}); n != 0 {
+		t.Errorf("Invalid allocations %f", n)
+	}
+}
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"iter"
 	"math"
 	"sort"
 	"sync"
@@ -18,15 +19,29 @@ func NewTTL(size int) *TTL {
 	if size <= 0 {
 		return nil
 	}
-	return &TTL{
-		Cache: New(size),
-		index: make(map[interface{}]int64, size),
-	}
+	return newTTL(New(size))
+}
 
+func newTTL(c *Cache) *TTL {
+	ttl := &TTL{
+		Cache: c,
+		index: make(map[interface{}]int64),
+	}
+	c.policy = ttl
+	return ttl
 }
 
 func (c *TTL) Get(k interface{}) (v interface{}, exp time.Time, err error) {
-	return c.Cache.Get(k)
+	v, exp, err = c.Cache.Get(k)
+	if err == nil && c.Cache.sliding {
+		// Keep the index in sync with the extended expiration
+		c.mu.Lock()
+		if _, ok := c.index[k]; ok {
+			c.track(k, exp)
+		}
+		c.mu.Unlock()
+	}
+	return
 }
 
 type ttl struct {
@@ -48,7 +63,7 @@ func (c *TTL) ttls() []ttl {
 	return ttls
 }
 
-func (c *TTL) set(x interface{}, exp time.Time) {
+func (c *TTL) track(x interface{}, exp time.Time) {
 	score := int64(math.MaxInt64)
 	if !exp.IsZero() {
 		score = exp.UnixNano()
@@ -60,39 +75,117 @@ func (c *TTL) set(x interface{}, exp time.Time) {
 // Set assigns a value to a key and sets the expiration time.
 // If the size limit is reached the oldest item stored is evicted to insert the new one
 func (c *TTL) Set(x, y interface{}, exp time.Time) (err error) {
+	exp = c.Cache.expires(exp)
 	c.mu.Lock()
-	if err = c.Cache.Set(x, y, exp); err != ErrMaxSize {
+	err = c.setLocked(x, y, exp)
+	c.mu.Unlock()
+	return
+}
+
+func (c *TTL) setLocked(x, y interface{}, exp time.Time) (err error) {
+	if err = c.Cache.set(x, y, exp); err != ErrMaxSize {
 		if err == nil {
-			c.set(x, exp)
+			c.track(x, exp)
 		}
-		c.mu.Unlock()
 		return
 	}
+	// The cache expiry index yields the soonest to expire item without sorting
+	admitted := false
+	for {
+		k, ok := c.Cache.soonest()
+		if !ok || c.Cache.protected(k) {
+			break
+		}
+		if !admitted {
+			if err = c.Cache.admit(x, y, k); err != nil {
+				return
+			}
+			admitted = true
+		}
+		delete(c.index, k)
+		c.Cache.discard(k)
+		if err = c.Cache.set(x, y, exp); err != ErrMaxSize {
+			if err == nil {
+				c.track(x, exp)
+			}
+			return
+		}
+	}
+	// Items that never expire or are protected by a namespace quota need a full scan
 	ttls := c.ttls()
 	for _, ttl := range ttls {
+		if c.Cache.protected(ttl.Key) {
+			continue
+		}
+		if !admitted {
+			if err = c.Cache.admit(x, y, ttl.Key); err != nil {
+				return
+			}
+			admitted = true
+		}
 		delete(c.index, ttl.Key)
-		c.Cache.Evict(ttl.Key)
-		if err = c.Cache.Set(x, y, exp); err != ErrMaxSize {
+		c.Cache.discard(ttl.Key)
+		if err = c.Cache.set(x, y, exp); err != ErrMaxSize {
 			if err == nil {
-				c.set(x, exp)
+				c.track(x, exp)
 			}
-			c.mu.Unlock()
 			return
 		}
 	}
-
 	return
 }
 
 func (c *TTL) Evict(keys ...interface{}) (n int) {
 	c.mu.Lock()
+	n = c.evictLocked(keys)
+	c.mu.Unlock()
+	return
+}
+
+func (c *TTL) evictLocked(keys []interface{}) int {
 	for _, k := range keys {
 		delete(c.index, k)
 	}
-	n = c.Cache.Evict(keys...)
-	c.mu.Unlock()
-	return
+	return c.Cache.Evict(keys...)
+}
+
+func (c *TTL) lockPolicy()   { c.mu.Lock() }
+func (c *TTL) unlockPolicy() { c.mu.Unlock() }
+
+// Ordered returns an iterator over a snapshot of the items from the soonest to expire.
+func (c *TTL) Ordered() iter.Seq2[interface{}, Item] {
+	return c.Cache.ordered()
+}
 
+func (c *TTL) orderLocked() []interface{} {
+	ttls := c.ttls()
+	keys := make([]interface{}, len(ttls))
+	for i := range ttls {
+		keys[i] = ttls[i].Key
+	}
+	return keys
+}
+
+func (c *TTL) forgetLocked(k interface{}) {
+	delete(c.index, k)
+}
+
+func (c *TTL) resetLocked() {
+	c.index = make(map[interface{}]int64)
+}
+
+// Touch sets the expiration time of a key without rewriting its value, see Cache.Touch.
+func (c *TTL) Touch(k interface{}, exp time.Time) bool {
+	exp = c.Cache.expires(exp)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	exp, ok := c.Cache.touch(k, exp)
+	if ok {
+		if _, tracked := c.index[k]; tracked {
+			c.track(k, exp)
+		}
+	}
+	return ok
 }
 
 func (c *TTL) Trim(now time.Time) []interface{} {
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,8 +18,10 @@ func (f UpstreamFunc) Get(x interface{}) (y interface{}, exp time.Time, err erro
 
 type blockingUpstream struct {
 	Upstream
+	tracer  Tracer
 	mu      sync.RWMutex
 	pending map[interface{}]*pending
+	shared  uint64
 }
 
 type pending struct {
@@ -28,17 +31,19 @@ type pending struct {
 	err   error
 }
 
-func (b *blockingUpstream) get(x interface{}) (p *pending) {
+func (b *blockingUpstream) get(x interface{}, span Span) (p *pending, shared bool) {
 	b.mu.RLock()
 	if p = b.pending[x]; p != nil {
 		b.mu.RUnlock()
-		return p
+		atomic.AddUint64(&b.shared, 1)
+		return p, true
 	}
 	b.mu.RUnlock()
 	b.mu.Lock()
 	if p = b.pending[x]; p != nil {
 		b.mu.Unlock()
-		return p
+		atomic.AddUint64(&b.shared, 1)
+		return p, true
 	}
 	p = &pending{}
 	p.wg.Add(1)
@@ -46,25 +51,68 @@ func (b *blockingUpstream) get(x interface{}) (p *pending) {
 	b.mu.Unlock()
 
 	go func() {
-		p.value, p.exp, p.err = b.Upstream.Get(x)
+		if b.tracer == nil {
+			p.value, p.exp, p.err = b.Upstream.Get(x)
+		} else {
+			fetch := b.tracer.Start(span, SpanFetch)
+			p.value, p.exp, p.err = b.Upstream.Get(x)
+			if p.err != nil {
+				fetch.SetAttribute(AttrError, p.err.Error())
+			}
+			fetch.End()
+		}
 		b.mu.Lock()
 		delete(b.pending, x)
 		b.mu.Unlock()
 		p.wg.Done()
 	}()
-	return p
+	return p, false
 }
 
 func (b *blockingUpstream) Get(x interface{}) (interface{}, time.Time, error) {
-	p := b.get(x)
+	return b.trace(nil, x)
+}
+
+// trace loads a key, spans are skipped if there is no tracer.
+func (b *blockingUpstream) trace(parent Span, x interface{}) (interface{}, time.Time, error) {
+	if b.tracer == nil {
+		p, _ := b.get(x, nil)
+		p.wg.Wait()
+		return p.value, p.exp, p.err
+	}
+	span := b.tracer.Start(parent, SpanLoad)
+	p, shared := b.get(x, span)
+	span.SetAttribute(AttrShared, shared)
 	p.wg.Wait()
+	if p.err != nil {
+		span.SetAttribute(AttrError, p.err.Error())
+	}
+	span.End()
 	return p.value, p.exp, p.err
 }
 
-// Blocking avoids multiple simultaneous requests for the same key
-func Blocking(up Upstream) Upstream {
+// Metrics reports the number of calls that were served by a load already in flight.
+func (b *blockingUpstream) Metrics() (m Metrics) {
+	m.Shared = atomic.LoadUint64(&b.shared)
+	return
+}
+
+func newBlocking(up Upstream) *blockingUpstream {
 	return &blockingUpstream{
 		Upstream: up,
 		pending:  make(map[interface{}]*pending),
 	}
 }
+
+// Blocking avoids multiple simultaneous requests for the same key.
+// The returned Upstream also implements Metrics() Metrics reporting shared calls.
+func Blocking(up Upstream) Upstream {
+	return newBlocking(up)
+}
+
+// TraceBlocking is like Blocking but traces loads and upstream requests with t.
+func TraceBlocking(up Upstream, t Tracer) Upstream {
+	b := newBlocking(up)
+	b.tracer = t
+	return b
+}
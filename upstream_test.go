@@ -39,3 +39,32 @@ func Test_Blocking(t *testing.T) {
 	}
 
 }
+
+func Test_ProxyNever(t *testing.T) {
+	var c cache.Interface = cache.New(0)
+	p := cache.Proxy(cache.UpstreamFunc(func(x interface{}) (interface{}, time.Time, error) {
+		return x, cache.Never(), nil
+	}), c)
+	// Misses and hits report the same expiration time
+	for i := 0; i < 2; i++ {
+		if v, exp, err := p.Get("foo"); err != nil || v != "foo" || !exp.IsZero() {
+			t.Errorf("Invalid Get %d %v %s %v", i, v, exp, err)
+		}
+	}
+	if m := p.(interface{ Metrics() cache.Metrics }).Metrics(); m.Load != 1 || m.Hit != 1 {
+		t.Errorf("Invalid metrics %v", m)
+	}
+	// Zero expiration times are reported with the default TTL the cache stored
+	c, err := cache.Build(cache.WithDefaultTTL(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p = cache.Proxy(cache.UpstreamFunc(func(x interface{}) (interface{}, time.Time, error) {
+		return x, time.Time{}, nil
+	}), c)
+	_, miss, _ := p.Get("foo")
+	_, hit, _ := p.Get("foo")
+	if miss.IsZero() || !miss.Equal(hit) {
+		t.Errorf("Invalid expiration %s != %s", miss, hit)
+	}
+}